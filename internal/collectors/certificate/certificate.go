@@ -0,0 +1,215 @@
+// Package certificate provides the certificate collector, which reports the
+// validity of X.509 certificates stored in local files.
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*certificateCollector)(nil)
+
+// certificateExtensions are the files picked up when scanning a directory,
+// so private keys and other files next to the certificates are ignored.
+var certificateExtensions = []string{".pem", ".crt", ".cer", ".cert", ".der"}
+
+func NewCertificateCollector(config *config.CertificateCollectorConfig) (prometheus.Collector, error) {
+	for _, path := range config.Paths {
+		if _, err := filepath.Match(path, ""); err != nil {
+			return nil, fmt.Errorf("invalid certificate path %q: %w", path, err)
+		}
+	}
+	certLabels := []string{"file", "fingerprint"}
+	return &certificateCollector{
+		config:        config,
+		notBefore:     prometheus.NewDesc("tls_cert_not_before_timestamp_seconds", "Start of the validity of the certificate since unix epoch in seconds", certLabels, nil),
+		notAfter:      prometheus.NewDesc("tls_cert_not_after_timestamp_seconds", "End of the validity of the certificate since unix epoch in seconds", certLabels, nil),
+		daysRemaining: prometheus.NewDesc("tls_cert_days_remaining", "Days until the certificate expires, negative once expired", certLabels, nil),
+		info:          prometheus.NewDesc("tls_cert_info", "Identity of the certificate", []string{"file", "fingerprint", "serial", "subject", "issuer", "sans", "is_ca"}, nil),
+		fileError:     prometheus.NewDesc("tls_cert_file_error", "Whether the certificate file could not be read or holds no certificate", []string{"file"}, nil),
+	}, nil
+}
+
+type certificateCollector struct {
+	config *config.CertificateCollectorConfig
+
+	notBefore     *prometheus.Desc
+	notAfter      *prometheus.Desc
+	daysRemaining *prometheus.Desc
+	info          *prometheus.Desc
+	fileError     *prometheus.Desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *certificateCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting certificate metrics")
+	if !c.config.Enabled {
+		slog.Warn("certificate metrics are not enabled")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.GetTimeout())
+	defer cancel()
+
+	now := time.Now()
+	for _, file := range certificateFiles(c.config.Paths) {
+		if ctx.Err() != nil {
+			slog.Error("certificate collection timed out", "error", ctx.Err())
+			return
+		}
+		certs, err := readCertificates(file)
+		if err != nil {
+			slog.Warn("failed to read certificates", "file", file, "error", err)
+			ch <- prometheus.MustNewConstMetric(c.fileError, prometheus.GaugeValue, 1, file)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.fileError, prometheus.GaugeValue, 0, file)
+
+		seen := make(map[string]bool, len(certs))
+		for _, cert := range certs {
+			// Serials are only unique per issuer, so certificates are told
+			// apart by their fingerprint
+			fingerprint := fingerprint(cert)
+			if seen[fingerprint] {
+				// The same certificate twice in a bundle
+				continue
+			}
+			seen[fingerprint] = true
+			serial := hex.EncodeToString(cert.SerialNumber.Bytes())
+			ch <- prometheus.MustNewConstMetric(c.notBefore, prometheus.GaugeValue, float64(cert.NotBefore.Unix()), file, fingerprint)
+			ch <- prometheus.MustNewConstMetric(c.notAfter, prometheus.GaugeValue, float64(cert.NotAfter.Unix()), file, fingerprint)
+			ch <- prometheus.MustNewConstMetric(c.daysRemaining, prometheus.GaugeValue, cert.NotAfter.Sub(now).Hours()/24, file, fingerprint)
+			ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1,
+				file, fingerprint, serial, cert.Subject.String(), cert.Issuer.String(), strings.Join(subjectAltNames(cert), ","), boolToString(cert.IsCA))
+		}
+	}
+}
+
+// certificateFiles expands the configured paths into the files to read.
+func certificateFiles(paths []string) []string {
+	var files []string
+	for _, path := range paths {
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			continue
+		}
+		if len(matches) == 0 {
+			// Report the missing file instead of silently ignoring it
+			matches = []string{path}
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || !info.IsDir() {
+				files = append(files, match)
+				continue
+			}
+			entries, err := os.ReadDir(match)
+			if err != nil {
+				slog.Warn("failed to list certificate directory", "directory", match, "error", err)
+				continue
+			}
+			for _, entry := range entries {
+				if entry.IsDir() || !slices.Contains(certificateExtensions, strings.ToLower(filepath.Ext(entry.Name()))) {
+					continue
+				}
+				files = append(files, filepath.Join(match, entry.Name()))
+			}
+		}
+	}
+	slices.Sort(files)
+	return slices.Compact(files)
+}
+
+// readCertificates parses every certificate of a PEM file, which may hold a
+// chain or a bundle, or of a DER file.
+func readCertificates(file string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var certs []*x509.Certificate
+	if bytes.Contains(data, []byte("-----BEGIN")) {
+		certs, err = parsePEM(data)
+	} else {
+		certs, err = x509.ParseCertificates(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate found")
+	}
+	return certs, nil
+}
+
+// parsePEM parses the CERTIFICATE blocks of PEM data, skipping other blocks
+// such as a private key stored in the same file.
+func parsePEM(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+}
+
+// fingerprint returns the hex SHA-256 digest of the DER encoding of cert.
+func fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// subjectAltNames returns the DNS names, IP addresses, email addresses and
+// URIs the certificate is valid for.
+func subjectAltNames(cert *x509.Certificate) []string {
+	sans := slices.Clone(cert.DNSNames)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+	return sans
+}
+
+func boolToString(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+// Describe implements prometheus.Collector.
+func (c *certificateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.notBefore
+	ch <- c.notAfter
+	ch <- c.daysRemaining
+	ch <- c.info
+	ch <- c.fileError
+}
@@ -0,0 +1,85 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+// newCertificate returns the PEM encoding of a self-signed certificate.
+func newCertificate(t *testing.T, issuer string, serial int64) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: issuer},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestCertificateCollectorBundle(t *testing.T) {
+	first := newCertificate(t, "First CA", 1)
+	tests := []struct {
+		name   string
+		bundle [][]byte
+		want   int
+	}{
+		{name: "same serial from different issuers", bundle: [][]byte{first, newCertificate(t, "Second CA", 1)}, want: 2},
+		{name: "same certificate twice", bundle: [][]byte{first, first}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "bundle.pem")
+			var data []byte
+			for _, cert := range tt.bundle {
+				data = append(data, cert...)
+			}
+			if err := os.WriteFile(file, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			collector, err := NewCertificateCollector(&config.CertificateCollectorConfig{
+				Usage: config.Usage{Enabled: true},
+				Paths: []string{file},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(collector)
+			families, err := registry.Gather()
+			if err != nil {
+				t.Fatalf("Gather() error = %v", err)
+			}
+			samples := make(map[string]int)
+			for _, family := range families {
+				samples[family.GetName()] = len(family.GetMetric())
+			}
+			for _, name := range []string{"tls_cert_not_before_timestamp_seconds", "tls_cert_not_after_timestamp_seconds", "tls_cert_days_remaining", "tls_cert_info"} {
+				if samples[name] != tt.want {
+					t.Errorf("%s has %d samples, want %d", name, samples[name], tt.want)
+				}
+			}
+		})
+	}
+}
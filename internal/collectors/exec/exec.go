@@ -0,0 +1,208 @@
+// Package exec provides the exec collector, which runs configured commands
+// and turns their output into metrics.
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/aide-family/laurel/internal/collectors/promtext"
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ promtext.Source = (*execCollector)(nil)
+
+// waitDelay bounds how long Wait blocks on output pipes after the command was
+// killed, in case a process that left the group still holds them open.
+const waitDelay = time.Second
+
+// NewExecCollector creates the exec collector. Output defining a metric for
+// which reserved returns true is dropped, so it cannot clash with the metrics
+// of the built-in collectors.
+func NewExecCollector(config *config.ExecCollectorConfig, reserved func(name string) bool) (promtext.Source, error) {
+	commandLabels := []string{"command"}
+	collector := &execCollector{
+		config:   config,
+		reserved: reserved,
+		success:  prometheus.NewDesc("exec_success", "Whether the command succeeded and its output was parsed", commandLabels, nil),
+		duration: prometheus.NewDesc("exec_duration_seconds", "Duration of the last run of the command", commandLabels, nil),
+		exitCode: prometheus.NewDesc("exec_exit_code", "Exit code of the last run of the command, -1 when it was killed", commandLabels, nil),
+	}
+	names := make(map[string]bool, len(config.Commands))
+	for i := range config.Commands {
+		command := &config.Commands[i]
+		if command.Name == "" || command.Command == "" {
+			return nil, fmt.Errorf("exec command %d needs a name and a command", i)
+		}
+		if names[command.Name] {
+			return nil, fmt.Errorf("duplicate exec command %q", command.Name)
+		}
+		names[command.Name] = true
+		switch command.Format {
+		case "", formatPrometheus, formatKeyValue, formatJSON:
+		default:
+			return nil, fmt.Errorf("exec command %q has unknown format %q", command.Name, command.Format)
+		}
+		collector.runners = append(collector.runners, &runner{command: command, defaultTimeout: config.GetTimeout()})
+	}
+	return collector, nil
+}
+
+type execCollector struct {
+	config   *config.ExecCollectorConfig
+	reserved func(name string) bool
+	runners  []*runner
+
+	success  *prometheus.Desc
+	duration *prometheus.Desc
+	exitCode *prometheus.Desc
+}
+
+// result is the outcome of one run of a command.
+type result struct {
+	families map[string]*dto.MetricFamily
+	err      error
+	duration time.Duration
+	exitCode int
+}
+
+// runner runs a command and caches its result for the configured interval.
+type runner struct {
+	command        *config.ExecCommand
+	defaultTimeout time.Duration
+
+	mu      sync.Mutex
+	lastRun time.Time
+	last    *result
+}
+
+// Collect implements prometheus.Collector.
+func (c *execCollector) Collect(ch chan<- prometheus.Metric) {
+	merger := promtext.NewMerger()
+	c.CollectFamilies(ch, merger)
+	merger.Collect(ch)
+}
+
+// CollectFamilies implements promtext.Source.
+func (c *execCollector) CollectFamilies(ch chan<- prometheus.Metric, merger *promtext.Merger) {
+	slog.Info("collecting exec metrics")
+	if !c.config.Enabled {
+		slog.Warn("exec metrics are not enabled")
+		return
+	}
+
+	results := make([]*result, len(c.runners))
+	var wg sync.WaitGroup
+	for i, r := range c.runners {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.result()
+		}()
+	}
+	wg.Wait()
+
+	for i, r := range c.runners {
+		name, res := r.command.Name, results[i]
+		ch <- prometheus.MustNewConstMetric(c.success, prometheus.GaugeValue, boolToFloat(res.err == nil), name)
+		ch <- prometheus.MustNewConstMetric(c.duration, prometheus.GaugeValue, res.duration.Seconds(), name)
+		ch <- prometheus.MustNewConstMetric(c.exitCode, prometheus.GaugeValue, float64(res.exitCode), name)
+		if res.err != nil {
+			continue
+		}
+		c.merge(name, res.families, merger)
+	}
+}
+
+// merge adds the families of one command to merger one at a time, dropping
+// those that would make the registry fail the scrape: reserved names, and
+// families clashing with a textfile or another command in type, help or
+// samples.
+func (c *execCollector) merge(command string, parsed map[string]*dto.MetricFamily, merger *promtext.Merger) {
+	for name, family := range parsed {
+		if c.reserved != nil && c.reserved(name) {
+			slog.Warn("exec metric conflicts with a built-in metric", "command", command, "metric", name)
+			continue
+		}
+		if err := merger.Add(map[string]*dto.MetricFamily{name: family}); err != nil {
+			slog.Warn("dropped exec metric", "command", command, "metric", name, "error", err)
+		}
+	}
+}
+
+// result returns the cached result, running the command when the interval
+// has elapsed since the last run.
+func (r *runner) result() *result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.last != nil && time.Since(r.lastRun) < r.command.Interval {
+		return r.last
+	}
+	r.lastRun = time.Now()
+	r.last = r.run()
+	if r.last.err != nil {
+		slog.Warn("exec command failed", "command", r.command.Name, "error", r.last.err)
+	}
+	return r.last
+}
+
+func (r *runner) run() *result {
+	timeout := r.command.Timeout
+	if timeout <= 0 {
+		timeout = r.defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, r.command.Command, r.command.Args...)
+	cmd.Dir = r.command.Dir
+	cmd.Env = append(os.Environ(), r.command.Env...)
+	cmd.WaitDelay = waitDelay
+	killProcessGroup(cmd)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	res := &result{duration: time.Since(start), exitCode: -1}
+	if cmd.ProcessState != nil {
+		res.exitCode = cmd.ProcessState.ExitCode()
+	}
+	if ctx.Err() != nil {
+		res.err = fmt.Errorf("command timed out after %s", timeout)
+		return res
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stderr.Len() > 0 {
+			err = fmt.Errorf("%w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		res.err = err
+		return res
+	}
+	res.families, res.err = parseOutput(r.command.Name, r.command.Format, stdout.Bytes())
+	return res
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Describe implements prometheus.Collector. The metrics depend on the output
+// of the commands, so the collector is registered unchecked.
+func (c *execCollector) Describe(ch chan<- *prometheus.Desc) {
+}
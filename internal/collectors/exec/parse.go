@@ -0,0 +1,132 @@
+package exec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/aide-family/laurel/internal/collectors/promtext"
+)
+
+const (
+	formatPrometheus = "prometheus"
+	formatKeyValue   = "keyvalue"
+	formatJSON       = "json"
+)
+
+// parseOutput turns the standard output of the command into metric families.
+// Key/value and JSON output become gauges labelled with the command name.
+func parseOutput(command, format string, output []byte) (map[string]*dto.MetricFamily, error) {
+	switch format {
+	case "", formatPrometheus:
+		return promtext.Parse(bytes.NewReader(output))
+	case formatKeyValue:
+		values, err := parseKeyValue(output)
+		if err != nil {
+			return nil, err
+		}
+		return gaugeFamilies(command, values)
+	case formatJSON:
+		var doc map[string]any
+		if err := json.Unmarshal(output, &doc); err != nil {
+			return nil, fmt.Errorf("invalid JSON output: %w", err)
+		}
+		values := make(map[string]float64)
+		flattenJSON("", doc, values)
+		return gaugeFamilies(command, values)
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// parseKeyValue parses "key value" lines, skipping blank lines and comments.
+func parseKeyValue(output []byte) (map[string]float64, error) {
+	values := make(map[string]float64)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected \"key value\", got %q", line, text)
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid value %q", line, fields[1])
+		}
+		values[fields[0]] = value
+	}
+	return values, scanner.Err()
+}
+
+// flattenJSON collects the numeric and boolean leaves of a JSON object, joining
+// the keys of nested objects with underscores.
+func flattenJSON(prefix string, doc map[string]any, values map[string]float64) {
+	for key, value := range doc {
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+		switch v := value.(type) {
+		case float64:
+			values[key] = v
+		case bool:
+			values[key] = 0
+			if v {
+				values[key] = 1
+			}
+		case map[string]any:
+			flattenJSON(key, v, values)
+		}
+	}
+}
+
+func gaugeFamilies(command string, values map[string]float64) (map[string]*dto.MetricFamily, error) {
+	families := make(map[string]*dto.MetricFamily, len(values))
+	for key, value := range values {
+		name := sanitizeName(key)
+		if !model.LegacyValidation.IsValidMetricName(name) {
+			return nil, fmt.Errorf("key %q is not a valid metric name", key)
+		}
+		if _, ok := families[name]; ok {
+			return nil, fmt.Errorf("key %q collides with another key after sanitizing", key)
+		}
+		families[name] = &dto.MetricFamily{
+			Name: proto.String(name),
+			Help: proto.String("Value reported by an exec command"),
+			Type: dto.MetricType_GAUGE.Enum(),
+			Metric: []*dto.Metric{{
+				Label: []*dto.LabelPair{{Name: proto.String("command"), Value: proto.String(command)}},
+				Gauge: &dto.Gauge{Value: proto.Float64(value)},
+			}},
+		}
+	}
+	return families, nil
+}
+
+// sanitizeName replaces the characters not allowed in metric names.
+func sanitizeName(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r == ':':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,163 @@
+package exec
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestParseKeyValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    map[string]float64
+		wantErr bool
+	}{
+		{
+			name:   "values",
+			output: "queue_length 3\n\n# comment\n  backups_total 1.5e3  \nratio -0.25\n",
+			want:   map[string]float64{"queue_length": 3, "backups_total": 1500, "ratio": -0.25},
+		},
+		{name: "empty", output: "", want: map[string]float64{}},
+		{name: "missing value", output: "queue_length\n", wantErr: true},
+		{name: "extra field", output: "queue_length 3 4\n", wantErr: true},
+		{name: "invalid value", output: "queue_length three\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseKeyValue([]byte(tt.output))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseKeyValue() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !maps.Equal(got, tt.want) {
+				t.Errorf("parseKeyValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlattenJSON(t *testing.T) {
+	doc := map[string]any{
+		"healthy": true,
+		"broken":  false,
+		"queue":   float64(3),
+		"name":    "ignored",
+		"list":    []any{float64(1)},
+		"null":    nil,
+		"disk": map[string]any{
+			"used": float64(10),
+			"io":   map[string]any{"reads": float64(7)},
+		},
+	}
+	want := map[string]float64{
+		"healthy":       1,
+		"broken":        0,
+		"queue":         3,
+		"disk_used":     10,
+		"disk_io_reads": 7,
+	}
+	got := make(map[string]float64)
+	flattenJSON("", doc, got)
+	if !maps.Equal(got, want) {
+		t.Errorf("flattenJSON() = %v, want %v", got, want)
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	tests := map[string]string{
+		"queue_length":    "queue_length",
+		"queue-length":    "queue_length",
+		"disk.used bytes": "disk_used_bytes",
+		"9lives":          "_9lives",
+		"http:requests":   "http:requests",
+		"temp°C":          "temp_C",
+		"":                "",
+		"Mixed_Case_2":    "Mixed_Case_2",
+	}
+	for key, want := range tests {
+		if got := sanitizeName(key); got != want {
+			t.Errorf("sanitizeName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestGaugeFamilies(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]float64
+		want    []string
+		wantErr string
+	}{
+		{
+			name:   "sanitized names",
+			values: map[string]float64{"queue-length": 3, "disk.used": 10},
+			want:   []string{"queue_length", "disk_used"},
+		},
+		{
+			name:    "collision after sanitizing",
+			values:  map[string]float64{"queue-length": 3, "queue.length": 4},
+			wantErr: "collides",
+		},
+		{
+			name:    "invalid name",
+			values:  map[string]float64{"": 3},
+			wantErr: "not a valid metric name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			families, err := gaugeFamilies("backup", tt.values)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("gaugeFamilies() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("gaugeFamilies() error = %v", err)
+			}
+			if len(families) != len(tt.want) {
+				t.Fatalf("gaugeFamilies() returned %d families, want %d", len(families), len(tt.want))
+			}
+			for _, name := range tt.want {
+				family, ok := families[name]
+				if !ok {
+					t.Fatalf("gaugeFamilies() did not return %s", name)
+				}
+				label := family.GetMetric()[0].GetLabel()[0]
+				if label.GetName() != "command" || label.GetValue() != "backup" {
+					t.Errorf("%s labels = %v, want command=backup", name, family.GetMetric()[0].GetLabel())
+				}
+			}
+		})
+	}
+}
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		format  string
+		output  string
+		want    []string
+		wantErr bool
+	}{
+		{format: "", output: "# TYPE queue_length gauge\nqueue_length 3\n", want: []string{"queue_length"}},
+		{format: formatPrometheus, output: "queue_length{queue=\"a\"} 3\n", want: []string{"queue_length"}},
+		{format: formatKeyValue, output: "queue_length 3\n", want: []string{"queue_length"}},
+		{format: formatJSON, output: `{"queue": {"length": 3}}`, want: []string{"queue_length"}},
+		{format: formatJSON, output: `[1, 2]`, wantErr: true},
+		{format: "yaml", output: "queue_length: 3\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			families, err := parseOutput("backup", tt.format, []byte(tt.output))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseOutput() error = %v, want error %v", err, tt.wantErr)
+			}
+			for _, name := range tt.want {
+				if _, ok := families[name]; !ok {
+					t.Errorf("parseOutput() = %v, want %s", families, name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package exec
+
+import "os/exec"
+
+// killProcessGroup keeps the default behaviour of killing only the command
+// itself, process groups are a Unix concept.
+func killProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package exec
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup starts the command in its own process group and makes
+// cancellation kill the whole group, so children of a shell script do not
+// outlive a timeout and keep the output pipes open.
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,89 @@
+//go:build unix
+
+package exec
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+// processExists reports whether pid is a live process. Zombies count as gone,
+// they only wait for a parent that may not reap them in a container.
+func processExists(pid int) bool {
+	if err := syscall.Kill(pid, 0); errors.Is(err, syscall.ESRCH) {
+		return false
+	}
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return !errors.Is(err, os.ErrNotExist)
+	}
+	// The state follows the parenthesised command name
+	_, rest, _ := strings.Cut(string(stat), ") ")
+	return !strings.HasPrefix(rest, "Z")
+}
+
+func TestTimeoutKillsProcessGroup(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "sleep.pid")
+	timeout := 300 * time.Millisecond
+	collector, err := NewExecCollector(&config.ExecCollectorConfig{
+		Usage: config.Usage{Enabled: true},
+		Commands: []config.ExecCommand{{
+			Name:    "hang",
+			Command: "sh",
+			// The background sleep inherits the output pipe
+			Args:    []string{"-c", `sleep 30 & echo $! > "$0"; wait`, pidFile},
+			Timeout: timeout,
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	start := time.Now()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= timeout+waitDelay {
+		t.Errorf("run took %v, want less than %v", elapsed, timeout+waitDelay)
+	}
+
+	values := make(map[string]float64)
+	for _, family := range families {
+		values[family.GetName()] = family.GetMetric()[0].GetGauge().GetValue()
+	}
+	if values["exec_exit_code"] != -1 {
+		t.Errorf("exec_exit_code = %v, want -1", values["exec_exit_code"])
+	}
+	if values["exec_success"] != 0 {
+		t.Errorf("exec_success = %v, want 0", values["exec_success"])
+	}
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(time.Second); processExists(pid); {
+		if time.Now().After(deadline) {
+			syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatalf("background process %d outlived the timeout", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,184 @@
+// Package filewatch provides the file watch collector, which reports how
+// many files match configured paths and how fresh they are.
+package filewatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*fileWatchCollector)(nil)
+
+func NewFileWatchCollector(config *config.FileWatchCollectorConfig) (prometheus.Collector, error) {
+	names := make(map[string]bool, len(config.Watches))
+	for i, watch := range config.Watches {
+		if watch.Name == "" || len(watch.Paths) == 0 {
+			return nil, fmt.Errorf("file watch %d needs a name and paths", i)
+		}
+		if names[watch.Name] {
+			return nil, fmt.Errorf("duplicate file watch %q", watch.Name)
+		}
+		names[watch.Name] = true
+		for _, pattern := range watch.Paths {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("file watch %q has invalid path %q: %w", watch.Name, pattern, err)
+			}
+		}
+	}
+
+	watchLabels := []string{"watch"}
+	return &fileWatchCollector{
+		config:      config,
+		files:       prometheus.NewDesc("filewatch_files", "Number of files matching the watch", watchLabels, nil),
+		size:        prometheus.NewDesc("filewatch_size_bytes", "Total size of the files matching the watch", watchLabels, nil),
+		newestMtime: prometheus.NewDesc("filewatch_newest_mtime_seconds", "Modification time of the newest matching file since unix epoch in seconds", watchLabels, nil),
+		oldestMtime: prometheus.NewDesc("filewatch_oldest_mtime_seconds", "Modification time of the oldest matching file since unix epoch in seconds", watchLabels, nil),
+		stale:       prometheus.NewDesc("filewatch_stale", "Whether no file matches or the newest one is older than the max age", watchLabels, nil),
+	}, nil
+}
+
+type fileWatchCollector struct {
+	config *config.FileWatchCollectorConfig
+
+	files       *prometheus.Desc
+	size        *prometheus.Desc
+	newestMtime *prometheus.Desc
+	oldestMtime *prometheus.Desc
+	stale       *prometheus.Desc
+}
+
+// fileStats summarizes the files matching a watch.
+type fileStats struct {
+	count  int
+	size   int64
+	newest time.Time
+	oldest time.Time
+}
+
+func (s *fileStats) add(info fs.FileInfo) {
+	s.count++
+	s.size += info.Size()
+	mtime := info.ModTime()
+	if s.newest.IsZero() || mtime.After(s.newest) {
+		s.newest = mtime
+	}
+	if s.oldest.IsZero() || mtime.Before(s.oldest) {
+		s.oldest = mtime
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *fileWatchCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting file watch metrics")
+	if !c.config.Enabled {
+		slog.Warn("file watch metrics are not enabled")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.GetTimeout())
+	defer cancel()
+
+	now := time.Now()
+	for _, watch := range c.config.Watches {
+		stats, err := statFiles(ctx, watch.Paths)
+		if err != nil {
+			// A partial result would look like missing files, report nothing
+			slog.Error("failed to get watched files", "watch", watch.Name, "error", err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.files, prometheus.GaugeValue, float64(stats.count), watch.Name)
+		ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(stats.size), watch.Name)
+		stale := stats.count == 0
+		if stats.count > 0 {
+			ch <- prometheus.MustNewConstMetric(c.newestMtime, prometheus.GaugeValue, float64(stats.newest.UnixNano())/1e9, watch.Name)
+			ch <- prometheus.MustNewConstMetric(c.oldestMtime, prometheus.GaugeValue, float64(stats.oldest.UnixNano())/1e9, watch.Name)
+			stale = watch.MaxAge > 0 && now.Sub(stats.newest) > watch.MaxAge
+		}
+		ch <- prometheus.MustNewConstMetric(c.stale, prometheus.GaugeValue, boolToFloat(stale), watch.Name)
+	}
+}
+
+// statFiles summarizes the regular files matching the patterns, walking
+// matching directories. A file matched by several patterns counts once.
+func statFiles(ctx context.Context, patterns []string) (*fileStats, error) {
+	stats := &fileStats{}
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			// Stat follows a symlink matched by the pattern itself, links
+			// below walked directories are skipped.
+			info, err := os.Stat(match)
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				return nil, err
+			}
+			if !info.IsDir() {
+				if info.Mode().IsRegular() && !seen[match] {
+					seen[match] = true
+					stats.add(info)
+				}
+				continue
+			}
+			err = filepath.WalkDir(match, func(path string, entry fs.DirEntry, err error) error {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if err != nil {
+					// Removed while walking
+					if errors.Is(err, fs.ErrNotExist) {
+						return nil
+					}
+					return err
+				}
+				if !entry.Type().IsRegular() || seen[path] {
+					return nil
+				}
+				info, err := entry.Info()
+				if err != nil {
+					if errors.Is(err, fs.ErrNotExist) {
+						return nil
+					}
+					return err
+				}
+				seen[path] = true
+				stats.add(info)
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return stats, nil
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Describe implements prometheus.Collector.
+func (c *fileWatchCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.files
+	ch <- c.size
+	ch <- c.newestMtime
+	ch <- c.oldestMtime
+	ch <- c.stale
+}
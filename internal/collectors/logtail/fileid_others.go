@@ -0,0 +1,11 @@
+//go:build !unix
+
+package logtail
+
+import "os"
+
+// fileID returns 0, inodes are a Unix concept. Rotation by rename goes
+// unnoticed, truncation is still detected.
+func fileID(info os.FileInfo) uint64 {
+	return 0
+}
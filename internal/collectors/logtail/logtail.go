@@ -0,0 +1,242 @@
+// Package logtail provides the log tail collector, which follows log files
+// and turns lines matching regular expressions into metrics.
+package logtail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*logTailCollector)(nil)
+
+const (
+	typeCounter   = "counter"
+	typeHistogram = "histogram"
+)
+
+// NewLogTailCollector creates the log tail collector. Files are opened at
+// their end, or at the offset kept in the state file, so old lines are not
+// counted. Rules defining a metric for which reserved returns true are
+// rejected.
+func NewLogTailCollector(config *config.LogTailCollectorConfig, reserved func(name string) bool) (prometheus.Collector, error) {
+	collector := &logTailCollector{
+		config:    config,
+		lines:     prometheus.NewCounterVec(prometheus.CounterOpts{Name: "logtail_lines_total", Help: "Lines read from the log file"}, []string{"file"}),
+		rotations: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "logtail_rotations_total", Help: "Rotations of the log file, by rename or truncation"}, []string{"file", "kind"}),
+	}
+	if !config.Enabled {
+		return collector, nil
+	}
+
+	names := make(map[string]bool)
+	for _, file := range config.Files {
+		var rules []*rule
+		for _, ruleConfig := range file.Rules {
+			if names[ruleConfig.Name] || (reserved != nil && reserved(ruleConfig.Name)) {
+				return nil, fmt.Errorf("log tail metric %q is already defined", ruleConfig.Name)
+			}
+			names[ruleConfig.Name] = true
+			r, err := newRule(&ruleConfig)
+			if err != nil {
+				return nil, fmt.Errorf("log tail rule %q: %w", ruleConfig.Name, err)
+			}
+			rules = append(rules, r)
+		}
+		collector.files = append(collector.files, &logFile{path: file.Path, rules: rules})
+	}
+
+	state, err := loadState(config.StateFile)
+	if err != nil {
+		slog.Warn("failed to load log tail state, starting at the end of the files", "error", err)
+	}
+	for _, file := range collector.files {
+		file.tailer = newTailer(file.path, state[file.path])
+	}
+	return collector, nil
+}
+
+type logTailCollector struct {
+	config *config.LogTailCollectorConfig
+
+	// mu serializes reading the files, scrapes may run concurrently.
+	mu    sync.Mutex
+	files []*logFile
+	// saved is the state last written to the state file.
+	saved map[string]*fileState
+
+	lines     *prometheus.CounterVec
+	rotations *prometheus.CounterVec
+}
+
+// logFile is a followed file and the rules its lines are matched against.
+type logFile struct {
+	path   string
+	rules  []*rule
+	tailer *tailer
+}
+
+// rule is a compiled LogRule.
+type rule struct {
+	regex *regexp.Regexp
+	// labels are the indexes of the capture groups exported as labels.
+	labels []int
+	// value is the index of the value capture group, -1 to count lines.
+	value     int
+	counter   *prometheus.CounterVec
+	histogram *prometheus.HistogramVec
+}
+
+func newRule(config *config.LogRule) (*rule, error) {
+	if !model.LegacyValidation.IsValidMetricName(config.Name) {
+		return nil, errors.New("invalid metric name")
+	}
+	re, err := regexp.Compile(config.Regex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex: %w", err)
+	}
+	r := &rule{regex: re, value: -1}
+	var labelNames []string
+	for i, name := range re.SubexpNames() {
+		switch {
+		case name == "":
+		case name == config.Value:
+			r.value = i
+		default:
+			r.labels = append(r.labels, i)
+			labelNames = append(labelNames, name)
+		}
+	}
+	if config.Value != "" && r.value < 0 {
+		return nil, fmt.Errorf("regex has no capture group %q", config.Value)
+	}
+
+	help := config.Help
+	if help == "" {
+		help = "Lines of the log file matching " + config.Regex
+	}
+	switch config.Type {
+	case "", typeCounter:
+		r.counter = prometheus.NewCounterVec(prometheus.CounterOpts{Name: config.Name, Help: help}, labelNames)
+	case typeHistogram:
+		if r.value < 0 {
+			return nil, errors.New("histogram needs a value capture group")
+		}
+		buckets := config.Buckets
+		if len(buckets) == 0 {
+			buckets = prometheus.DefBuckets
+		}
+		if !slices.IsSorted(buckets) {
+			return nil, errors.New("buckets must be sorted")
+		}
+		r.histogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: config.Name, Help: help, Buckets: buckets}, labelNames)
+	default:
+		return nil, fmt.Errorf("unknown type %q", config.Type)
+	}
+	return r, nil
+}
+
+// apply updates the metric of the rule when the line matches.
+func (r *rule) apply(line string) {
+	m := r.regex.FindStringSubmatch(line)
+	if m == nil {
+		return
+	}
+	labels := make([]string, len(r.labels))
+	for i, group := range r.labels {
+		labels[i] = m[group]
+	}
+	value := 1.0
+	if r.value >= 0 {
+		v, err := strconv.ParseFloat(m[r.value], 64)
+		if err != nil {
+			slog.Debug("log line value is not a number", "value", m[r.value], "error", err)
+			return
+		}
+		value = v
+	}
+	if r.histogram != nil {
+		r.histogram.WithLabelValues(labels...).Observe(value)
+		return
+	}
+	if value < 0 {
+		// Counters cannot go down
+		return
+	}
+	r.counter.WithLabelValues(labels...).Add(value)
+}
+
+func (r *rule) collector() prometheus.Collector {
+	if r.histogram != nil {
+		return r.histogram
+	}
+	return r.counter
+}
+
+// Collect implements prometheus.Collector. The files are read up to their
+// current end before the metrics are collected.
+func (c *logTailCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting log tail metrics")
+	if !c.config.Enabled {
+		slog.Warn("log tail metrics are not enabled")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.GetTimeout())
+	defer cancel()
+
+	c.mu.Lock()
+	state := make(map[string]*fileState, len(c.files))
+	for _, file := range c.files {
+		err := file.tailer.poll(ctx, func(line string) {
+			c.lines.WithLabelValues(file.path).Inc()
+			for _, r := range file.rules {
+				r.apply(line)
+			}
+		}, func(kind string) {
+			c.rotations.WithLabelValues(file.path, kind).Inc()
+		})
+		if err != nil {
+			slog.Error("failed to read log file", "file", file.path, "error", err)
+		}
+		if fileState := file.tailer.state(); fileState != nil {
+			state[file.path] = fileState
+		}
+	}
+	if !equalState(state, c.saved) {
+		if err := saveState(c.config.StateFile, state); err != nil {
+			slog.Error("failed to save log tail state", "error", err)
+		} else {
+			c.saved = state
+		}
+	}
+	c.mu.Unlock()
+
+	c.lines.Collect(ch)
+	c.rotations.Collect(ch)
+	for _, file := range c.files {
+		for _, r := range file.rules {
+			r.collector().Collect(ch)
+		}
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *logTailCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.lines.Describe(ch)
+	c.rotations.Describe(ch)
+	for _, file := range c.files {
+		for _, r := range file.rules {
+			r.collector().Describe(ch)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package logtail
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+)
+
+// fileState is the position in a log file kept across restarts.
+type fileState struct {
+	ID     uint64 `json:"id"`
+	Offset int64  `json:"offset"`
+	// Fingerprint is a hash of the first FingerprintSize bytes of the file,
+	// to notice it was truncated and rewritten while laurel was down.
+	Fingerprint     uint64 `json:"fingerprint,omitempty"`
+	FingerprintSize int    `json:"fingerprint_size,omitempty"`
+}
+
+// loadState reads the state file, keyed by log file path. A missing state
+// file is not an error.
+func loadState(path string) (map[string]*fileState, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var state map[string]*fileState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// saveState replaces the state file, through a rename so a crash cannot
+// leave it half written.
+func saveState(path string, state map[string]*fileState) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// equalState reports whether two states hold the same positions.
+func equalState(a, b map[string]*fileState) bool {
+	return maps.EqualFunc(a, b, func(x, y *fileState) bool {
+		return *x == *y
+	})
+}
@@ -0,0 +1,252 @@
+package logtail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// maxLineSize bounds the memory held for a line without a trailing newline
+// yet. Longer lines are dropped.
+const maxLineSize = 1 << 20
+
+// headSize is how much of the start of a file is remembered to recognise it
+// after it was truncated and written past the read offset again.
+const headSize = 256
+
+const (
+	rotationRename   = "rename"
+	rotationTruncate = "truncate"
+)
+
+// tailer follows a file across rotations. A file renamed away (logrotate's
+// default) is detected by the path pointing to a different inode, in which
+// case the rest of the old file is read before switching to the new one. A
+// file truncated in place (copytruncate) is detected by its size dropping
+// below the read offset, or by its start no longer matching the bytes read
+// from it when it grew past the offset again between two polls.
+type tailer struct {
+	path string
+
+	file   *os.File
+	reader *bufio.Reader
+	id     uint64
+	// offset is the end of the last complete line read.
+	offset int64
+	// head is the start of the file read so far, up to headSize bytes.
+	head []byte
+	// pending is a line whose newline has not been written yet, taking
+	// pendingSize bytes of the file. dropping is set once it grew past
+	// maxLineSize.
+	pending     []byte
+	pendingSize int64
+	dropping    bool
+}
+
+// newTailer opens the file at the saved offset when the state refers to the
+// same file, at its start when it was rotated since, and at its end when
+// there is no state. A file that does not exist yet is read from its start
+// once it appears.
+func newTailer(path string, saved *fileState) *tailer {
+	t := &tailer{path: path}
+	info, err := os.Stat(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("failed to stat log file", "file", path, "error", err)
+		}
+		return t
+	}
+	offset := info.Size()
+	if saved != nil {
+		offset = 0
+		if saved.ID == fileID(info) && saved.Offset <= info.Size() {
+			offset = saved.Offset
+		}
+	}
+	if err := t.open(offset); err != nil {
+		slog.Warn("failed to open log file", "file", path, "error", err)
+		return t
+	}
+	// State files written before the fingerprint was kept have none
+	if offset > 0 && saved != nil && saved.FingerprintSize > 0 && (len(t.head) != saved.FingerprintSize || fingerprint(t.head) != saved.Fingerprint) {
+		slog.Warn("log file was rewritten since the saved state, reading it from the start", "file", path)
+		t.close()
+		if err := t.open(0); err != nil {
+			slog.Warn("failed to open log file", "file", path, "error", err)
+		}
+	}
+	return t
+}
+
+func (t *tailer) open(offset int64) error {
+	file, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if offset > info.Size() {
+		offset = 0
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return err
+	}
+	t.file, t.id, t.offset = file, fileID(info), offset
+	t.reader = bufio.NewReader(file)
+	t.pending, t.pendingSize, t.dropping = nil, 0, false
+	t.head = nil
+	return t.updateHead()
+}
+
+func (t *tailer) close() {
+	if t.file != nil {
+		t.file.Close()
+	}
+	t.file, t.reader, t.pending, t.head = nil, nil, nil, nil
+}
+
+// readHead returns up to n bytes from the start of the file.
+func (t *tailer) readHead(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	read, err := t.file.ReadAt(buf, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return buf[:read], nil
+}
+
+// updateHead remembers the start of the file up to the read offset.
+func (t *tailer) updateHead() error {
+	n := int(min(t.offset, headSize))
+	if n <= len(t.head) {
+		return nil
+	}
+	head, err := t.readHead(n)
+	if err != nil {
+		return err
+	}
+	t.head = head
+	return nil
+}
+
+// truncated reports whether the file was truncated in place since it was
+// last read.
+func (t *tailer) truncated(size int64) (bool, error) {
+	if size < t.offset {
+		return true, nil
+	}
+	head, err := t.readHead(len(t.head))
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(head, t.head), nil
+}
+
+// poll reads the lines appended since the last call, calling rotated when it
+// notices a rotation.
+func (t *tailer) poll(ctx context.Context, handle func(line string), rotated func(kind string)) error {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		// Renamed away or deleted, finish the old file and close it once a
+		// poll finds nothing new, so it does not stay open forever when the
+		// path is not recreated
+		if t.file == nil {
+			return nil
+		}
+		position := t.offset + t.pendingSize
+		if err := t.read(ctx, handle); err != nil {
+			return err
+		}
+		if t.offset+t.pendingSize == position {
+			t.close()
+		}
+		return nil
+	}
+
+	switch {
+	case t.file == nil:
+		if err := t.open(0); err != nil {
+			return err
+		}
+	case fileID(info) != t.id:
+		if err := t.read(ctx, handle); err != nil {
+			return err
+		}
+		t.close()
+		rotated(rotationRename)
+		if err := t.open(0); err != nil {
+			return err
+		}
+	default:
+		truncated, err := t.truncated(info.Size())
+		if err != nil {
+			return err
+		}
+		if truncated {
+			t.close()
+			rotated(rotationTruncate)
+			if err := t.open(0); err != nil {
+				return err
+			}
+		}
+	}
+	return t.read(ctx, handle)
+}
+
+// read handles the complete lines up to the end of the file.
+func (t *tailer) read(ctx context.Context, handle func(line string)) error {
+	for ctx.Err() == nil {
+		chunk, err := t.reader.ReadSlice('\n')
+		t.pendingSize += int64(len(chunk))
+		if !t.dropping {
+			if len(t.pending)+len(chunk) > maxLineSize {
+				slog.Warn("dropping overlong log line", "file", t.path)
+				t.pending, t.dropping = t.pending[:0], true
+			} else {
+				t.pending = append(t.pending, chunk...)
+			}
+		}
+		switch {
+		case err == nil:
+			t.offset += t.pendingSize
+			if !t.dropping {
+				// Strip the line ending, including the \r of CRLF logs
+				line := bytes.TrimSuffix(t.pending[:len(t.pending)-1], []byte("\r"))
+				handle(string(line))
+			}
+			t.pending, t.pendingSize, t.dropping = t.pending[:0], 0, false
+		case errors.Is(err, bufio.ErrBufferFull):
+		case errors.Is(err, io.EOF):
+			return t.updateHead()
+		default:
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// state returns the position to resume from after a restart.
+func (t *tailer) state() *fileState {
+	if t.file == nil {
+		return nil
+	}
+	return &fileState{ID: t.id, Offset: t.offset, Fingerprint: fingerprint(t.head), FingerprintSize: len(t.head)}
+}
+
+func fingerprint(head []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(head)
+	return h.Sum64()
+}
@@ -0,0 +1,291 @@
+package logtail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// poll polls the tailer once, returning the lines read and rotations seen.
+func poll(t *testing.T, tl *tailer) (lines, rotations []string) {
+	t.Helper()
+	err := tl.poll(context.Background(), func(line string) {
+		lines = append(lines, line)
+	}, func(kind string) {
+		rotations = append(rotations, kind)
+	})
+	if err != nil {
+		t.Fatalf("poll() error = %v", err)
+	}
+	return lines, rotations
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expect(t *testing.T, what string, got, want []string) {
+	t.Helper()
+	if !slices.Equal(got, want) {
+		t.Errorf("%s = %q, want %q", what, got, want)
+	}
+}
+
+func TestTailerRenameRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	tl := newTailer(path, nil)
+	defer tl.close()
+
+	writeFile(t, path, "a\nb\n")
+	lines, rotations := poll(t, tl)
+	expect(t, "lines", lines, []string{"a", "b"})
+	expect(t, "rotations", rotations, nil)
+
+	// Lines written to the old file before the rename are not lost
+	appendFile(t, path, "c\n")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	lines, _ = poll(t, tl)
+	expect(t, "lines after rename", lines, []string{"c"})
+
+	writeFile(t, path, "d\n")
+	lines, rotations = poll(t, tl)
+	expect(t, "lines after recreate", lines, []string{"d"})
+	expect(t, "rotations", rotations, []string{rotationRename})
+}
+
+func TestTailerCopyTruncate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rewrite string
+		want    []string
+	}{
+		{name: "shorter than the offset", rewrite: "x\n", want: []string{"x"}},
+		{name: "longer than the offset", rewrite: "first line\nsecond line\nthird line\n", want: []string{"first line", "second line", "third line"}},
+		{name: "as long as the offset", rewrite: "c\nd\n", want: []string{"c", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			writeFile(t, path, "a\nb\n")
+			tl := newTailer(path, &fileState{})
+			defer tl.close()
+			poll(t, tl)
+
+			writeFile(t, path, tt.rewrite)
+			lines, rotations := poll(t, tl)
+			expect(t, "lines", lines, tt.want)
+			expect(t, "rotations", rotations, []string{rotationTruncate})
+		})
+	}
+}
+
+func TestTailerCopyTruncateClosesFile(t *testing.T) {
+	if _, err := os.Stat("/proc/self/fd"); err != nil {
+		t.Skip("no /proc/self/fd")
+	}
+	path := filepath.Join(t.TempDir(), "app.log")
+	writeFile(t, path, "a\nb\n")
+	tl := newTailer(path, &fileState{})
+	defer tl.close()
+	poll(t, tl)
+
+	openFiles := func() int {
+		entries, err := os.ReadDir("/proc/self/fd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(entries)
+	}
+	before := openFiles()
+	for range 20 {
+		writeFile(t, path, "")
+		poll(t, tl)
+		writeFile(t, path, "a\nb\n")
+		poll(t, tl)
+	}
+	if after := openFiles(); after > before {
+		t.Errorf("open files grew from %d to %d", before, after)
+	}
+}
+
+func TestTailerPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	writeFile(t, path, "")
+	tl := newTailer(path, nil)
+	defer tl.close()
+
+	appendFile(t, path, "par")
+	lines, _ := poll(t, tl)
+	expect(t, "lines", lines, nil)
+	if state := tl.state(); state.Offset != 0 {
+		t.Errorf("offset = %d, want 0 while the line is incomplete", state.Offset)
+	}
+
+	appendFile(t, path, "tial\nnext")
+	lines, _ = poll(t, tl)
+	expect(t, "lines", lines, []string{"partial"})
+	if state := tl.state(); state.Offset != int64(len("partial\n")) {
+		t.Errorf("offset = %d, want %d", state.Offset, len("partial\n"))
+	}
+
+	appendFile(t, path, "\n")
+	lines, _ = poll(t, tl)
+	expect(t, "lines", lines, []string{"next"})
+}
+
+func TestTailerResume(t *testing.T) {
+	tests := []struct {
+		name string
+		// change is applied to the file while the tailer is stopped
+		change func(t *testing.T, path string)
+		// saved edits the state before resuming
+		saved func(state *fileState)
+		want  []string
+	}{
+		{
+			name:   "appended",
+			change: func(t *testing.T, path string) { appendFile(t, path, "c\n") },
+			want:   []string{"c"},
+		},
+		{
+			name:   "rewritten past the offset",
+			change: func(t *testing.T, path string) { writeFile(t, path, "new 1\nnew 2\nnew 3\n") },
+			want:   []string{"new 1", "new 2", "new 3"},
+		},
+		{
+			name:   "replaced by another file",
+			change: func(t *testing.T, path string) { appendFile(t, path, "c\n") },
+			saved:  func(state *fileState) { state.ID++ },
+			want:   []string{"a", "b", "c"},
+		},
+		{
+			name:   "state without fingerprint",
+			change: func(t *testing.T, path string) { appendFile(t, path, "c\n") },
+			saved:  func(state *fileState) { state.Fingerprint, state.FingerprintSize = 0, 0 },
+			want:   []string{"c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "app.log")
+			statePath := filepath.Join(dir, "state.json")
+			writeFile(t, path, "a\nb\n")
+			tl := newTailer(path, &fileState{})
+			poll(t, tl)
+			state := tl.state()
+			tl.close()
+			if tt.saved != nil {
+				tt.saved(state)
+			}
+			if err := saveState(statePath, map[string]*fileState{path: state}); err != nil {
+				t.Fatal(err)
+			}
+
+			tt.change(t, path)
+			loaded, err := loadState(statePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equalState(loaded, map[string]*fileState{path: state}) {
+				t.Fatalf("loadState() = %v, want %v", loaded, state)
+			}
+			tl = newTailer(path, loaded[path])
+			defer tl.close()
+			lines, _ := poll(t, tl)
+			expect(t, "lines", lines, tt.want)
+		})
+	}
+}
+
+func TestTailerStartsAtEndWithoutState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	writeFile(t, path, strings.Repeat("old\n", 3))
+	tl := newTailer(path, nil)
+	defer tl.close()
+
+	appendFile(t, path, "new\n")
+	lines, _ := poll(t, tl)
+	expect(t, "lines", lines, []string{"new"})
+}
+
+func TestTailerClosesFileRemovedForGood(t *testing.T) {
+	tests := []struct {
+		name   string
+		remove func(path string) error
+	}{
+		{name: "renamed", remove: func(path string) error { return os.Rename(path, path+".1") }},
+		{name: "deleted", remove: os.Remove},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			writeFile(t, path, "")
+			tl := newTailer(path, nil)
+			defer tl.close()
+
+			appendFile(t, path, "a\n")
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			if err := tt.remove(path); err != nil {
+				t.Fatal(err)
+			}
+			// The writer still has the file open after it was removed
+			if _, err := f.WriteString("b\n"); err != nil {
+				t.Fatal(err)
+			}
+			lines, _ := poll(t, tl)
+			expect(t, "lines", lines, []string{"a", "b"})
+			if tl.file == nil {
+				t.Fatal("file closed while lines were still being read")
+			}
+
+			lines, _ = poll(t, tl)
+			expect(t, "lines", lines, nil)
+			if tl.file != nil {
+				t.Error("file still open after it was drained and the path is gone")
+			}
+			if state := tl.state(); state != nil {
+				t.Errorf("state() = %+v, want nil", state)
+			}
+
+			writeFile(t, path, "c\n")
+			lines, _ = poll(t, tl)
+			expect(t, "lines after recreate", lines, []string{"c"})
+		})
+	}
+}
+
+func TestTailerStripsCarriageReturn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	writeFile(t, path, "")
+	tl := newTailer(path, nil)
+	defer tl.close()
+
+	appendFile(t, path, "GET /health 200\r\nno carriage return\n\r\nkeeps inner \r returns\r\n")
+	lines, _ := poll(t, tl)
+	expect(t, "lines", lines, []string{"GET /health 200", "no carriage return", "", "keeps inner \r returns"})
+}
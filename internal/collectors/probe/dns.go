@@ -0,0 +1,88 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+type dnsProber struct {
+	config    *config.Probe
+	queryType string
+	resolver  *net.Resolver
+}
+
+func newDNSProber(probe *config.Probe) (prober, error) {
+	queryType := strings.ToUpper(probe.DNS.QueryType)
+	switch queryType {
+	case "":
+		queryType = "A"
+	case "A", "AAAA", "CNAME", "MX", "NS", "TXT":
+	default:
+		return nil, fmt.Errorf("unsupported query type %q", probe.DNS.QueryType)
+	}
+	p := &dnsProber{config: probe, queryType: queryType, resolver: net.DefaultResolver}
+	if server := probe.DNS.Server; server != "" {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		p.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, server)
+			},
+		}
+	}
+	return p, nil
+}
+
+func (p *dnsProber) probe(ctx context.Context, result *result) {
+	start := time.Now()
+	answers, err := p.lookup(ctx)
+	result.addPhase("lookup", start, time.Now())
+	result.answers = answers
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		result.fail(reasonAnswer, err)
+	case err != nil:
+		result.fail(failureReason(err), err)
+	case answers == 0:
+		result.fail(reasonAnswer, fmt.Errorf("no %s records for %s", p.queryType, p.config.Target))
+	}
+}
+
+// lookup resolves the target and returns the number of records found.
+func (p *dnsProber) lookup(ctx context.Context) (int, error) {
+	name := p.config.Target
+	switch p.queryType {
+	case "A", "AAAA":
+		network := "ip4"
+		if p.queryType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := p.resolver.LookupNetIP(ctx, network, name)
+		return len(ips), err
+	case "CNAME":
+		cname, err := p.resolver.LookupCNAME(ctx, name)
+		if err != nil || cname == "" {
+			return 0, err
+		}
+		return 1, nil
+	case "MX":
+		records, err := p.resolver.LookupMX(ctx, name)
+		return len(records), err
+	case "NS":
+		records, err := p.resolver.LookupNS(ctx, name)
+		return len(records), err
+	default:
+		records, err := p.resolver.LookupTXT(ctx, name)
+		return len(records), err
+	}
+}
@@ -0,0 +1,154 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+// maxBodySize bounds how much of the response body is matched against the
+// body regex.
+const maxBodySize = 10 << 20
+
+type httpProber struct {
+	config    *config.Probe
+	client    *http.Client
+	bodyRegex *regexp.Regexp
+}
+
+func newHTTPProber(probe *config.Probe) (prober, error) {
+	p := &httpProber{
+		config: probe,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				// Every probe opens a new connection, so the connect and
+				// TLS phases are measured each time.
+				DisableKeepAlives: true,
+				TLSClientConfig:   &tls.Config{InsecureSkipVerify: probe.HTTP.InsecureSkipVerify},
+			},
+		},
+	}
+	if probe.HTTP.BodyRegex != "" {
+		re, err := regexp.Compile(probe.HTTP.BodyRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body regex: %w", err)
+		}
+		p.bodyRegex = re
+	}
+	return p, nil
+}
+
+func (p *httpProber) probe(ctx context.Context, result *result) {
+	method := p.config.HTTP.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	trace := &httpTrace{}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace.clientTrace()), method, p.config.Target, nil)
+	if err != nil {
+		result.fail(reasonError, err)
+		return
+	}
+	for key, value := range p.config.HTTP.Headers {
+		if http.CanonicalHeaderKey(key) == "Host" {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(key, value)
+	}
+
+	var transferEnd time.Time
+	defer func() {
+		trace.addPhases(result, transferEnd)
+	}()
+	resp, err := p.client.Do(req)
+	if err != nil {
+		result.fail(failureReason(err), err)
+		return
+	}
+	defer resp.Body.Close()
+
+	result.statusCode = resp.StatusCode
+	result.observeCerts(resp.TLS)
+	var body []byte
+	if p.bodyRegex != nil {
+		body, err = io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	} else {
+		_, err = io.Copy(io.Discard, resp.Body)
+	}
+	transferEnd = time.Now()
+	if err != nil {
+		result.fail(failureReason(err), err)
+		return
+	}
+
+	if !p.validStatus(resp.StatusCode) {
+		result.fail(reasonStatus, fmt.Errorf("unexpected status %s", resp.Status))
+		return
+	}
+	if p.bodyRegex != nil && !p.bodyRegex.Match(body) {
+		result.fail(reasonBody, fmt.Errorf("body does not match %q", p.bodyRegex))
+	}
+}
+
+// validStatus reports whether the status code is one of the configured
+// codes, or any 2xx status when none are configured.
+func (p *httpProber) validStatus(code int) bool {
+	if len(p.config.HTTP.ValidStatusCodes) == 0 {
+		return code >= 200 && code < 300
+	}
+	return slices.Contains(p.config.HTTP.ValidStatusCodes, code)
+}
+
+// httpTrace records when the phases of a request start and end. The hooks run
+// on the transport goroutines, and a dial can outlive the request when it
+// times out or is canceled, so the times are guarded by mu.
+type httpTrace struct {
+	mu sync.Mutex
+
+	dnsStart, dnsDone         time.Time
+	connectStart, connectDone time.Time
+	tlsStart, tlsDone         time.Time
+	gotConn, firstByte        time.Time
+}
+
+func (t *httpTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.mark(&t.dnsDone) },
+		ConnectStart:         func(string, string) { t.mark(&t.connectStart) },
+		ConnectDone:          func(string, string, error) { t.mark(&t.connectDone) },
+		TLSHandshakeStart:    func() { t.mark(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.mark(&t.tlsDone) },
+		GotConn:              func(httptrace.GotConnInfo) { t.mark(&t.gotConn) },
+		GotFirstResponseByte: func() { t.mark(&t.firstByte) },
+	}
+}
+
+func (t *httpTrace) mark(at *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*at = time.Now()
+}
+
+// addPhases adds the phases seen so far to result, with the transfer ending
+// at transferEnd once the body was read.
+func (t *httpTrace) addPhases(result *result, transferEnd time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	result.addPhase("resolve", t.dnsStart, t.dnsDone)
+	result.addPhase("connect", t.connectStart, t.connectDone)
+	result.addPhase("tls", t.tlsStart, t.tlsDone)
+	result.addPhase("processing", t.gotConn, t.firstByte)
+	result.addPhase("transfer", t.firstByte, transferEnd)
+}
@@ -0,0 +1,54 @@
+package probe
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+func TestHTTPProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		path   string
+		reason string
+		// phases are the phases the probe must report
+		phases []string
+	}{
+		{name: "success", path: "/", phases: []string{"connect", "processing", "transfer"}},
+		{name: "timeout", path: "/slow", reason: reasonTimeout, phases: []string{"connect"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &config.Probe{Name: tt.name, Type: typeHTTP, Target: server.URL + tt.path, Timeout: 200 * time.Millisecond}
+			prober, err := newHTTPProber(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := (&probe{config: target, prober: prober}).run(time.Second)
+			if res.reason != tt.reason {
+				t.Errorf("reason = %q, want %q (error %v)", res.reason, tt.reason, res.err)
+			}
+			phases := make(map[string]bool)
+			for _, phase := range res.phases {
+				phases[phase.name] = true
+			}
+			for _, name := range tt.phases {
+				if !phases[name] {
+					t.Errorf("phases = %v, want %s", res.phases, name)
+				}
+			}
+		})
+	}
+}
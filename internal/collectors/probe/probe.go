@@ -0,0 +1,282 @@
+// Package probe provides the probe collector, which checks HTTP, TCP and DNS
+// endpoints on every scrape.
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*probeCollector)(nil)
+
+const (
+	typeHTTP = "http"
+	typeTCP  = "tcp"
+	typeDNS  = "dns"
+)
+
+// Failure reasons reported by probe_last_failure_info. They are a fixed set
+// so the label cannot grow without bound.
+const (
+	reasonTimeout = "timeout"
+	reasonResolve = "resolve"
+	reasonConnect = "connect"
+	reasonTLS     = "tls"
+	reasonStatus  = "status"
+	reasonBody    = "body"
+	reasonSend    = "send"
+	reasonExpect  = "expect"
+	reasonAnswer  = "no_answer"
+	reasonError   = "error"
+)
+
+func NewProbeCollector(config *config.ProbeCollectorConfig) (prometheus.Collector, error) {
+	probeLabels := []string{"probe", "type"}
+	collector := &probeCollector{
+		config:       config,
+		success:      prometheus.NewDesc("probe_success", "Whether the probe succeeded", probeLabels, nil),
+		duration:     prometheus.NewDesc("probe_duration_seconds", "Duration of the probe", probeLabels, nil),
+		phase:        prometheus.NewDesc("probe_phase_duration_seconds", "Duration of a phase of the probe", []string{"probe", "type", "phase"}, nil),
+		lastFailure:  prometheus.NewDesc("probe_last_failure_info", "Reason of the last failure of the probe", []string{"probe", "type", "reason"}, nil),
+		lastFailedAt: prometheus.NewDesc("probe_last_failure_timestamp_seconds", "Time of the last failure of the probe since unix epoch in seconds", probeLabels, nil),
+		statusCode:   prometheus.NewDesc("probe_http_status_code", "Status code of the HTTP response", []string{"probe"}, nil),
+		certExpiry:   prometheus.NewDesc("probe_tls_cert_expiry_timestamp_seconds", "Earliest expiry of the certificates presented by the server since unix epoch in seconds", probeLabels, nil),
+		answers:      prometheus.NewDesc("probe_dns_answers", "Number of records in the DNS answer", []string{"probe"}, nil),
+	}
+	names := make(map[string]bool, len(config.Targets))
+	for i := range config.Targets {
+		target := &config.Targets[i]
+		if target.Name == "" || target.Target == "" {
+			return nil, fmt.Errorf("probe %d needs a name and a target", i)
+		}
+		if names[target.Name] {
+			return nil, fmt.Errorf("duplicate probe %q", target.Name)
+		}
+		names[target.Name] = true
+
+		var (
+			prober prober
+			err    error
+		)
+		switch target.Type {
+		case typeHTTP:
+			prober, err = newHTTPProber(target)
+		case typeTCP:
+			prober, err = newTCPProber(target)
+		case typeDNS:
+			prober, err = newDNSProber(target)
+		default:
+			err = fmt.Errorf("unknown type %q", target.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("probe %q: %w", target.Name, err)
+		}
+		collector.probes = append(collector.probes, &probe{config: target, prober: prober})
+	}
+	return collector, nil
+}
+
+type probeCollector struct {
+	config *config.ProbeCollectorConfig
+	probes []*probe
+
+	success      *prometheus.Desc
+	duration     *prometheus.Desc
+	phase        *prometheus.Desc
+	lastFailure  *prometheus.Desc
+	lastFailedAt *prometheus.Desc
+	statusCode   *prometheus.Desc
+	certExpiry   *prometheus.Desc
+	answers      *prometheus.Desc
+}
+
+// prober checks one endpoint, recording what it observed in result.
+type prober interface {
+	probe(ctx context.Context, result *result)
+}
+
+// probe is a configured endpoint and the last failure seen for it.
+type probe struct {
+	config *config.Probe
+	prober prober
+
+	mu          sync.Mutex
+	lastFailure *result
+	failedAt    time.Time
+}
+
+// phase is a named part of a probe, e.g. the TCP connect.
+type phase struct {
+	name     string
+	duration time.Duration
+}
+
+// result is the outcome of one run of a probe.
+type result struct {
+	duration time.Duration
+	phases   []phase
+	// reason and err are set when the probe failed.
+	reason string
+	err    error
+
+	statusCode int
+	certExpiry time.Time
+	answers    int
+}
+
+func (r *result) addPhase(name string, start, end time.Time) {
+	if start.IsZero() || end.IsZero() {
+		return
+	}
+	r.phases = append(r.phases, phase{name: name, duration: end.Sub(start)})
+}
+
+// fail records the failure, keeping the first one when called again.
+func (r *result) fail(reason string, err error) {
+	if r.err != nil {
+		return
+	}
+	r.reason, r.err = reason, err
+}
+
+// observeCerts records the earliest expiry of the certificates the server
+// presented.
+func (r *result) observeCerts(state *tls.ConnectionState) {
+	if state == nil {
+		return
+	}
+	for _, cert := range state.PeerCertificates {
+		if r.certExpiry.IsZero() || cert.NotAfter.Before(r.certExpiry) {
+			r.certExpiry = cert.NotAfter
+		}
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *probeCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting probe metrics")
+	if !c.config.Enabled {
+		slog.Warn("probe metrics are not enabled")
+		return
+	}
+
+	results := make([]*result, len(c.probes))
+	var wg sync.WaitGroup
+	for i, p := range c.probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = p.run(c.config.GetTimeout())
+		}()
+	}
+	wg.Wait()
+
+	for i, p := range c.probes {
+		name, kind, res := p.config.Name, p.config.Type, results[i]
+		ch <- prometheus.MustNewConstMetric(c.success, prometheus.GaugeValue, boolToFloat(res.err == nil), name, kind)
+		ch <- prometheus.MustNewConstMetric(c.duration, prometheus.GaugeValue, res.duration.Seconds(), name, kind)
+		for _, phase := range res.phases {
+			ch <- prometheus.MustNewConstMetric(c.phase, prometheus.GaugeValue, phase.duration.Seconds(), name, kind, phase.name)
+		}
+		if res.statusCode != 0 {
+			ch <- prometheus.MustNewConstMetric(c.statusCode, prometheus.GaugeValue, float64(res.statusCode), name)
+		}
+		if !res.certExpiry.IsZero() {
+			ch <- prometheus.MustNewConstMetric(c.certExpiry, prometheus.GaugeValue, float64(res.certExpiry.Unix()), name, kind)
+		}
+		if kind == typeDNS {
+			ch <- prometheus.MustNewConstMetric(c.answers, prometheus.GaugeValue, float64(res.answers), name)
+		}
+
+		p.mu.Lock()
+		if failure := p.lastFailure; failure != nil {
+			ch <- prometheus.MustNewConstMetric(c.lastFailure, prometheus.GaugeValue, 1, name, kind, failure.reason)
+			ch <- prometheus.MustNewConstMetric(c.lastFailedAt, prometheus.GaugeValue, float64(p.failedAt.UnixNano())/1e9, name, kind)
+		}
+		p.mu.Unlock()
+	}
+}
+
+// run probes the endpoint within the probe timeout, falling back to the
+// collector timeout.
+func (p *probe) run(defaultTimeout time.Duration) *result {
+	timeout := p.config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	res := &result{}
+	start := time.Now()
+	p.prober.probe(ctx, res)
+	res.duration = time.Since(start)
+	if res.err != nil && ctx.Err() != nil {
+		res.reason = reasonTimeout
+	}
+
+	if res.err != nil {
+		slog.Warn("probe failed", "probe", p.config.Name, "reason", res.reason, "error", res.err)
+		p.mu.Lock()
+		p.lastFailure, p.failedAt = res, time.Now()
+		p.mu.Unlock()
+	}
+	return res
+}
+
+// failureReason classifies a network error.
+func failureReason(err error) string {
+	var (
+		netErr     net.Error
+		dnsErr     *net.DNSError
+		opErr      *net.OpError
+		recordErr  tls.RecordHeaderError
+		verifyErr  *tls.CertificateVerificationError
+		unknownErr x509.UnknownAuthorityError
+		hostErr    x509.HostnameError
+		invalidErr x509.CertificateInvalidError
+	)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return reasonTimeout
+	case errors.As(err, &dnsErr):
+		return reasonResolve
+	case errors.As(err, &recordErr), errors.As(err, &verifyErr), errors.As(err, &unknownErr),
+		errors.As(err, &hostErr), errors.As(err, &invalidErr):
+		return reasonTLS
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return reasonConnect
+	default:
+		return reasonError
+	}
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Describe implements prometheus.Collector.
+func (c *probeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.success
+	ch <- c.duration
+	ch <- c.phase
+	ch <- c.lastFailure
+	ch <- c.lastFailedAt
+	ch <- c.statusCode
+	ch <- c.certExpiry
+	ch <- c.answers
+}
@@ -0,0 +1,147 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"time"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+// maxExpectSize bounds how much of the data read from the connection is
+// matched against the expect regex, older data is dropped first.
+const maxExpectSize = 64 << 10
+
+type tcpProber struct {
+	config *config.Probe
+	host   string
+	port   string
+	expect *regexp.Regexp
+}
+
+func newTCPProber(probe *config.Probe) (prober, error) {
+	host, port, err := net.SplitHostPort(probe.Target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target: %w", err)
+	}
+	p := &tcpProber{config: probe, host: host, port: port}
+	if probe.TCP.Expect != "" {
+		// Multi-line mode keeps ^ and $ matching at line boundaries, as when
+		// the regex was matched line by line
+		re, err := regexp.Compile("(?m)" + probe.TCP.Expect)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expect regex: %w", err)
+		}
+		p.expect = re
+	}
+	return p, nil
+}
+
+func (p *tcpProber) probe(ctx context.Context, result *result) {
+	start := time.Now()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, p.host)
+	if err != nil {
+		result.fail(failureReason(err), err)
+		return
+	}
+	connectStart := time.Now()
+	result.addPhase("resolve", start, connectStart)
+
+	conn, err := dialAny(ctx, addrs, p.port)
+	if err != nil {
+		result.fail(failureReason(err), err)
+		return
+	}
+	defer conn.Close()
+	exchangeStart := time.Now()
+	result.addPhase("connect", connectStart, exchangeStart)
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if p.config.TCP.TLS {
+		tlsConn := tls.Client(conn, &tls.Config{
+			ServerName:         p.host,
+			InsecureSkipVerify: p.config.TCP.InsecureSkipVerify,
+		})
+		err := tlsConn.HandshakeContext(ctx)
+		tlsDone := time.Now()
+		result.addPhase("tls", exchangeStart, tlsDone)
+		if err != nil {
+			result.fail(failureReason(err), err)
+			return
+		}
+		state := tlsConn.ConnectionState()
+		result.observeCerts(&state)
+		conn, exchangeStart = tlsConn, tlsDone
+	}
+
+	if p.config.TCP.Send == "" && p.expect == nil {
+		return
+	}
+	defer func() {
+		result.addPhase("exchange", exchangeStart, time.Now())
+	}()
+	if p.config.TCP.Send != "" {
+		if _, err := conn.Write([]byte(p.config.TCP.Send)); err != nil {
+			result.fail(reasonSend, err)
+			return
+		}
+	}
+	if p.expect == nil {
+		return
+	}
+	// The data read so far is matched after every read, so a banner without
+	// a trailing newline matches while the server keeps the connection open.
+	var data []byte
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		data = append(data, buf[:n]...)
+		if len(data) > maxExpectSize {
+			data = data[len(data)-maxExpectSize:]
+		}
+		if n > 0 && p.expect.Match(data) {
+			return
+		}
+		if errors.Is(err, io.EOF) {
+			result.fail(reasonExpect, fmt.Errorf("nothing matched %q before the connection was closed", p.config.TCP.Expect))
+			return
+		}
+		if err != nil {
+			result.fail(failureReason(err), err)
+			return
+		}
+	}
+}
+
+// dialAny connects to the addresses in turn, returning the first connection
+// established or the error of the first address. The addresses are already
+// resolved, so the connect phase does not include a second lookup.
+func dialAny(ctx context.Context, addrs []string, port string) (net.Conn, error) {
+	var (
+		dialer   net.Dialer
+		firstErr error
+	)
+	for _, addr := range addrs {
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(addr, port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if firstErr == nil {
+		firstErr = errors.New("no addresses to dial")
+	}
+	return nil, firstErr
+}
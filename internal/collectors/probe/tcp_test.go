@@ -0,0 +1,135 @@
+package probe
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+// tcpServer listens on 127.0.0.1 and hands every connection to serve.
+func tcpServer(t *testing.T, serve func(conn net.Conn)) (host, port string) {
+	t.Helper()
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				serve(conn)
+			}()
+		}
+	}()
+	host, port, _ = net.SplitHostPort(ln.Addr().String())
+	return host, port
+}
+
+func TestDialAnyTriesEveryAddress(t *testing.T) {
+	_, port := tcpServer(t, func(net.Conn) {})
+	// Nothing listens on 127.0.0.2, so the first connect is refused
+	conn, err := dialAny(context.Background(), []string{"127.0.0.2", "127.0.0.1"}, port)
+	if err != nil {
+		t.Fatalf("dialAny() error = %v", err)
+	}
+	defer conn.Close()
+	if got := conn.RemoteAddr().(*net.TCPAddr).IP.String(); got != "127.0.0.1" {
+		t.Errorf("connected to %s, want 127.0.0.1", got)
+	}
+
+	if _, err := dialAny(context.Background(), []string{"127.0.0.2"}, port); err == nil {
+		t.Error("dialAny() connected to an address nothing listens on")
+	}
+}
+
+func TestTCPProbeExpect(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect string
+		// serve writes the server side of the exchange
+		serve  func(conn net.Conn)
+		reason string
+	}{
+		{
+			name:   "banner without newline on an open connection",
+			expect: `^\+OK ready`,
+			serve: func(conn net.Conn) {
+				conn.Write([]byte("+OK ready"))
+				io.Copy(io.Discard, conn)
+			},
+		},
+		{
+			name:   "line anchors",
+			expect: `^220 .*ESMTP$`,
+			serve: func(conn net.Conn) {
+				conn.Write([]byte("220-mail.example.com hello\r\n220 mail.example.com ESMTP\n"))
+				io.Copy(io.Discard, conn)
+			},
+		},
+		{
+			name:   "banner split over several writes",
+			expect: `SSH-2\.0-OpenSSH`,
+			serve: func(conn net.Conn) {
+				conn.Write([]byte("SSH-2.0-"))
+				time.Sleep(20 * time.Millisecond)
+				conn.Write([]byte("OpenSSH_9.6\r\n"))
+				io.Copy(io.Discard, conn)
+			},
+		},
+		{
+			name:   "closed without a match",
+			expect: `^\+OK`,
+			serve: func(conn net.Conn) {
+				conn.Write([]byte("-ERR busy\r\n"))
+			},
+			reason: reasonExpect,
+		},
+		{
+			name:   "open without a match",
+			expect: `^\+OK`,
+			serve: func(conn net.Conn) {
+				conn.Write([]byte("-ERR busy"))
+				io.Copy(io.Discard, conn)
+			},
+			reason: reasonTimeout,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port := tcpServer(t, tt.serve)
+			target := &config.Probe{
+				Name:    "tcp",
+				Type:    typeTCP,
+				Target:  net.JoinHostPort(host, port),
+				Timeout: 300 * time.Millisecond,
+				TCP:     config.TCPProbe{Expect: tt.expect},
+			}
+			prober, err := newTCPProber(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := (&probe{config: target, prober: prober}).run(time.Second)
+			if res.reason != tt.reason {
+				t.Errorf("reason = %q, want %q (error %v)", res.reason, tt.reason, res.err)
+			}
+			phases := make(map[string]bool)
+			for _, phase := range res.phases {
+				phases[phase.name] = true
+			}
+			for _, name := range []string{"resolve", "connect", "exchange"} {
+				if !phases[name] {
+					t.Errorf("phases = %v, want %s", res.phases, name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package promtext
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// reservedPrefixes are the namespaces of the metrics the textfile and exec
+// collectors report about themselves.
+var reservedPrefixes = []string{"textfile_", "exec_"}
+
+// Reserved reports whether name belongs to the metrics the sources report
+// about themselves, which their parsed output must not define.
+func Reserved(name string) bool {
+	for _, prefix := range reservedPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Source is a collector of metrics read from files or commands. The registry
+// fails the whole scrape when two collectors emit a family with a different
+// type or help, or the same sample twice, so the families of all sources go
+// through one Merger.
+type Source interface {
+	prometheus.Collector
+	// CollectFamilies sends the metrics about the source itself to ch and
+	// adds the families it read to merger.
+	CollectFamilies(ch chan<- prometheus.Metric, merger *Merger)
+}
+
+// NewMergedCollector returns a collector that collects the sources in order
+// and merges their families, so a family already defined by an earlier source
+// is only extended by a later one when its type and help agree.
+func NewMergedCollector(sources ...Source) prometheus.Collector {
+	return &mergedCollector{sources: sources}
+}
+
+type mergedCollector struct {
+	sources []Source
+}
+
+// Collect implements prometheus.Collector.
+func (c *mergedCollector) Collect(ch chan<- prometheus.Metric) {
+	merger := NewMerger()
+	for _, source := range c.sources {
+		source.CollectFamilies(ch, merger)
+	}
+	merger.Collect(ch)
+}
+
+// Describe implements prometheus.Collector. The metrics depend on the files
+// and commands present at scrape time, so the collector is registered
+// unchecked.
+func (c *mergedCollector) Describe(ch chan<- *prometheus.Desc) {
+}
+
+// Merger merges metric families read from several places into one set that
+// the registry can gather without failing the scrape.
+type Merger struct {
+	families   map[string]*dto.MetricFamily
+	signatures map[string]struct{}
+}
+
+func NewMerger() *Merger {
+	return &Merger{
+		families:   make(map[string]*dto.MetricFamily),
+		signatures: make(map[string]struct{}),
+	}
+}
+
+// Check returns why families cannot be merged: a reserved name, a family whose
+// type or help differs from the one merged earlier, or a sample that is
+// repeated or already merged.
+func (m *Merger) Check(families map[string]*dto.MetricFamily) error {
+	seen := make(map[string]struct{})
+	for name, family := range families {
+		if Reserved(name) {
+			return fmt.Errorf("metric %s conflicts with a built-in metric", name)
+		}
+		if existing, ok := m.families[name]; ok {
+			if existing.GetType() != family.GetType() {
+				return fmt.Errorf("metric %s has type %s, but %s elsewhere", name, family.GetType(), existing.GetType())
+			}
+			if existing.Help != nil && family.Help != nil && existing.GetHelp() != family.GetHelp() {
+				return fmt.Errorf("metric %s has help %q, but %q elsewhere", name, family.GetHelp(), existing.GetHelp())
+			}
+		}
+		for _, metric := range family.GetMetric() {
+			signature := Signature(name, metric)
+			_, merged := m.signatures[signature]
+			_, repeated := seen[signature]
+			if merged || repeated {
+				return fmt.Errorf("sample %s is already defined", signature)
+			}
+			seen[signature] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// Add merges families after checking them, merging none of them on error.
+func (m *Merger) Add(families map[string]*dto.MetricFamily) error {
+	if err := m.Check(families); err != nil {
+		return err
+	}
+	for name, family := range families {
+		for _, metric := range family.GetMetric() {
+			m.signatures[Signature(name, metric)] = struct{}{}
+		}
+		existing, ok := m.families[name]
+		if !ok {
+			m.families[name] = &dto.MetricFamily{Name: family.Name, Help: family.Help, Type: family.Type, Metric: slices.Clone(family.Metric)}
+			continue
+		}
+		if existing.Help == nil {
+			existing.Help = family.Help
+		}
+		existing.Metric = append(existing.Metric, family.GetMetric()...)
+	}
+	return nil
+}
+
+// Collect sends the samples of the merged families to ch.
+func (m *Merger) Collect(ch chan<- prometheus.Metric) {
+	for name, family := range m.families {
+		metrics, err := Metrics(family)
+		if err != nil {
+			slog.Warn("failed to convert metric", "metric", name, "error", err)
+			continue
+		}
+		for _, metric := range metrics {
+			ch <- metric
+		}
+	}
+}
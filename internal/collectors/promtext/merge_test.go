@@ -0,0 +1,114 @@
+package promtext_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/aide-family/laurel/internal/collectors/exec"
+	"github.com/aide-family/laurel/internal/collectors/promtext"
+	"github.com/aide-family/laurel/internal/collectors/textfile"
+	"github.com/aide-family/laurel/internal/config"
+)
+
+func TestMergedCollector(t *testing.T) {
+	tests := []struct {
+		name     string
+		textfile string
+		format   string
+		output   string
+		// want maps the expected families to their number of samples
+		want map[string]int
+		// parseError is the expected textfile_parse_error value
+		parseError float64
+	}{
+		{
+			name:     "type clash drops the exec family",
+			textfile: "# TYPE queue_length counter\nqueue_length 5\n",
+			format:   "keyvalue",
+			output:   "queue_length 3\n",
+			want:     map[string]int{"queue_length": 1},
+		},
+		{
+			name:     "same type merges samples",
+			textfile: "# TYPE queue_length gauge\nqueue_length{command=\"cron\"} 5\n",
+			format:   "keyvalue",
+			output:   "queue_length 3\n",
+			want:     map[string]int{"queue_length": 2},
+		},
+		{
+			name:     "repeated sample drops the exec family",
+			textfile: "# TYPE queue_length gauge\nqueue_length{command=\"test\"} 5\n",
+			format:   "keyvalue",
+			output:   "queue_length 3\n",
+			want:     map[string]int{"queue_length": 1},
+		},
+		{
+			name:       "textfile defining an exec metric is rejected",
+			textfile:   "exec_success 1\n",
+			format:     "keyvalue",
+			output:     "queue_length 3\n",
+			want:       map[string]int{"queue_length": 1},
+			parseError: 1,
+		},
+		{
+			name:     "exec defining a textfile metric is dropped",
+			textfile: "queue_length 5\n",
+			format:   "prometheus",
+			output:   "textfile_mtime_seconds{file=\"x\"} 1\n",
+			want:     map[string]int{"queue_length": 1, "textfile_mtime_seconds": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "test.prom"), []byte(tt.textfile), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			textfileSource, err := textfile.NewTextfileCollector(&config.TextfileCollectorConfig{
+				Usage:       config.Usage{Enabled: true},
+				Directories: []string{dir},
+			}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			execSource, err := exec.NewExecCollector(&config.ExecCollectorConfig{
+				Usage: config.Usage{Enabled: true},
+				Commands: []config.ExecCommand{{
+					Name:    "test",
+					Command: "printf",
+					Args:    []string{"%s", tt.output},
+					Format:  tt.format,
+				}},
+			}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(promtext.NewMergedCollector(textfileSource, execSource))
+			families, err := registry.Gather()
+			if err != nil {
+				t.Fatalf("Gather() error = %v", err)
+			}
+			got := make(map[string]*dto.MetricFamily)
+			for _, family := range families {
+				got[family.GetName()] = family
+			}
+			for name, samples := range tt.want {
+				if n := len(got[name].GetMetric()); n != samples {
+					t.Errorf("%s has %d samples, want %d", name, n, samples)
+				}
+			}
+			if value := got["textfile_parse_error"].GetMetric()[0].GetGauge().GetValue(); value != tt.parseError {
+				t.Errorf("textfile_parse_error = %v, want %v", value, tt.parseError)
+			}
+			if value := got["exec_success"].GetMetric()[0].GetGauge().GetValue(); value != 1 {
+				t.Errorf("exec_success = %v, want 1", value)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+// Package promtext converts metrics in the Prometheus text exposition format
+// into metrics a prometheus.Collector can send.
+package promtext
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+)
+
+// Parse reads metric families in the text exposition format. Samples with
+// explicit timestamps are rejected, since they would be served as if they
+// were scraped now.
+func Parse(r io.Reader) (map[string]*dto.MetricFamily, error) {
+	parser := expfmt.NewTextParser(model.LegacyValidation)
+	families, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return nil, err
+	}
+	for name, family := range families {
+		for _, m := range family.GetMetric() {
+			if m.TimestampMs != nil {
+				return nil, fmt.Errorf("metric %s has a timestamp, which is not supported", name)
+			}
+		}
+	}
+	return families, nil
+}
+
+// Signature identifies a sample of a family by its name and label values, so
+// callers merging several sources can reject duplicates before the registry
+// fails the whole scrape on them.
+func Signature(name string, m *dto.Metric) string {
+	pairs := make([]string, 0, len(m.GetLabel()))
+	for _, label := range m.GetLabel() {
+		pairs = append(pairs, label.GetName()+"="+label.GetValue())
+	}
+	sort.Strings(pairs)
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Metrics converts every sample of the family into a const metric.
+func Metrics(family *dto.MetricFamily) ([]prometheus.Metric, error) {
+	metrics := make([]prometheus.Metric, 0, len(family.GetMetric()))
+	for _, m := range family.GetMetric() {
+		metric, err := newMetric(family, m)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, nil
+}
+
+func newMetric(family *dto.MetricFamily, m *dto.Metric) (prometheus.Metric, error) {
+	names := make([]string, 0, len(m.GetLabel()))
+	values := make([]string, 0, len(m.GetLabel()))
+	for _, label := range m.GetLabel() {
+		names = append(names, label.GetName())
+		values = append(values, label.GetValue())
+	}
+	desc := prometheus.NewDesc(family.GetName(), family.GetHelp(), names, nil)
+
+	switch family.GetType() {
+	case dto.MetricType_COUNTER:
+		return prometheus.NewConstMetric(desc, prometheus.CounterValue, m.GetCounter().GetValue(), values...)
+	case dto.MetricType_GAUGE:
+		return prometheus.NewConstMetric(desc, prometheus.GaugeValue, m.GetGauge().GetValue(), values...)
+	case dto.MetricType_UNTYPED:
+		return prometheus.NewConstMetric(desc, prometheus.UntypedValue, m.GetUntyped().GetValue(), values...)
+	case dto.MetricType_SUMMARY:
+		quantiles := make(map[float64]float64, len(m.GetSummary().GetQuantile()))
+		for _, q := range m.GetSummary().GetQuantile() {
+			quantiles[q.GetQuantile()] = q.GetValue()
+		}
+		return prometheus.NewConstSummary(desc, m.GetSummary().GetSampleCount(), m.GetSummary().GetSampleSum(), quantiles, values...)
+	case dto.MetricType_HISTOGRAM:
+		buckets := make(map[float64]uint64, len(m.GetHistogram().GetBucket()))
+		for _, b := range m.GetHistogram().GetBucket() {
+			// The +Inf bucket is implied by the sample count
+			if !math.IsInf(b.GetUpperBound(), +1) {
+				buckets[b.GetUpperBound()] = b.GetCumulativeCount()
+			}
+		}
+		return prometheus.NewConstHistogram(desc, m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum(), buckets, values...)
+	default:
+		return nil, errors.New("unsupported metric type " + family.GetType().String())
+	}
+}
@@ -0,0 +1,111 @@
+package promtext
+
+import (
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		// want maps the expected families to their number of samples
+		want    map[string]int
+		wantErr bool
+	}{
+		{
+			name:  "typed families",
+			input: "# HELP jobs_total Jobs run.\n# TYPE jobs_total counter\njobs_total{result=\"ok\"} 3\njobs_total{result=\"failed\"} 1\n# TYPE queue_length gauge\nqueue_length 5\n",
+			want:  map[string]int{"jobs_total": 2, "queue_length": 1},
+		},
+		{
+			name:  "histogram",
+			input: "# TYPE latency_seconds histogram\nlatency_seconds_bucket{le=\"0.1\"} 1\nlatency_seconds_bucket{le=\"+Inf\"} 2\nlatency_seconds_sum 0.3\nlatency_seconds_count 2\n",
+			want:  map[string]int{"latency_seconds": 1},
+		},
+		{
+			name:  "untyped",
+			input: "last_backup_timestamp_seconds 1.7e9\n",
+			want:  map[string]int{"last_backup_timestamp_seconds": 1},
+		},
+		{
+			name:    "invalid metric name",
+			input:   "9lives 1\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid value",
+			input:   "queue_length five\n",
+			wantErr: true,
+		},
+		{
+			name:    "repeated TYPE",
+			input:   "# TYPE queue_length gauge\n# TYPE queue_length counter\nqueue_length 5\n",
+			wantErr: true,
+		},
+		{
+			name:    "TYPE after the samples",
+			input:   "queue_length 5\n# TYPE queue_length gauge\n",
+			wantErr: true,
+		},
+		{
+			name:    "repeated HELP",
+			input:   "# HELP queue_length Jobs queued.\n# HELP queue_length Jobs waiting.\nqueue_length 5\n",
+			wantErr: true,
+		},
+		{
+			name:    "repeated label",
+			input:   "queue_length{queue=\"a\",queue=\"b\"} 5\n",
+			wantErr: true,
+		},
+		{
+			name:    "timestamp",
+			input:   "queue_length 5 1700000000000\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			families, err := Parse(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(families) != len(tt.want) {
+				t.Fatalf("Parse() returned %d families, want %d", len(families), len(tt.want))
+			}
+			for name, samples := range tt.want {
+				family, ok := families[name]
+				if !ok {
+					t.Fatalf("Parse() did not return %s", name)
+				}
+				if n := len(family.GetMetric()); n != samples {
+					t.Errorf("%s has %d samples, want %d", name, n, samples)
+				}
+				if _, err := Metrics(family); err != nil {
+					t.Errorf("Metrics(%s) error = %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSignature(t *testing.T) {
+	label := func(name, value string) *dto.LabelPair {
+		return &dto.LabelPair{Name: &name, Value: &value}
+	}
+	a := &dto.Metric{Label: []*dto.LabelPair{label("queue", "a"), label("host", "x")}}
+	b := &dto.Metric{Label: []*dto.LabelPair{label("host", "x"), label("queue", "a")}}
+	c := &dto.Metric{Label: []*dto.LabelPair{label("host", "x"), label("queue", "b")}}
+
+	if Signature("jobs", a) != Signature("jobs", b) {
+		t.Errorf("signatures differ with the label order: %s and %s", Signature("jobs", a), Signature("jobs", b))
+	}
+	if Signature("jobs", a) == Signature("jobs", c) {
+		t.Errorf("signatures of different label values are equal: %s", Signature("jobs", a))
+	}
+	if Signature("jobs", a) == Signature("other", a) {
+		t.Errorf("signatures of different families are equal: %s", Signature("jobs", a))
+	}
+}
@@ -0,0 +1,340 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*cgroupCollector)(nil)
+
+var (
+	// containerIDPattern matches the last path element of a container cgroup
+	// as created by Docker and podman with the systemd driver
+	// (docker-<id>.scope, libpod-<id>.scope), by containerd and CRI-O
+	// (cri-containerd-<id>.scope, crio-<id>.scope) and by the cgroupfs
+	// driver (/docker/<id>, /kubepods/burstable/pod<uid>/<id>).
+	containerIDPattern = regexp.MustCompile(`^(?:docker-|libpod-|cri-containerd-|containerd-|crio-)?([0-9a-f]{64})(?:\.scope)?$`)
+	// podUIDPattern matches the pod element of kubepods cgroups, where the
+	// systemd driver replaces the dashes of the UID with underscores.
+	podUIDPattern = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})`)
+)
+
+func NewCgroupCollector(config *config.CgroupUsage) (prometheus.Collector, error) {
+	include, err := compilePatterns(config.Include)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cgroup include pattern: %w", err)
+	}
+	exclude, err := compilePatterns(config.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cgroup exclude pattern: %w", err)
+	}
+	collector := &cgroupCollector{
+		config:           config,
+		root:             cgroupV2Root(),
+		include:          include,
+		exclude:          exclude,
+		cpuUsage:         newCgroupDesc("cpu_usage_seconds_total", "Total CPU time consumed by the cgroup"),
+		cpuUser:          newCgroupDesc("cpu_user_seconds_total", "Total user CPU time consumed by the cgroup"),
+		cpuSystem:        newCgroupDesc("cpu_system_seconds_total", "Total system CPU time consumed by the cgroup"),
+		cpuPeriods:       newCgroupDesc("cpu_periods_total", "Total number of enforcement periods of the CPU bandwidth limit"),
+		cpuThrottled:     newCgroupDesc("cpu_throttled_periods_total", "Total number of periods the cgroup was throttled in"),
+		cpuThrottledTime: newCgroupDesc("cpu_throttled_seconds_total", "Total time the cgroup was throttled"),
+		memoryCurrent:    newCgroupDesc("memory_current_bytes", "Memory currently used by the cgroup"),
+		memoryMax:        newCgroupDesc("memory_max_bytes", "Memory usage hard limit of the cgroup, absent when unlimited"),
+		memoryEvents:     newCgroupDesc("memory_events_total", "Total number of memory events of the cgroup", "event"),
+		ioReadBytes:      newCgroupDesc("io_read_bytes_total", "Total bytes read by the cgroup", "device"),
+		ioWriteBytes:     newCgroupDesc("io_write_bytes_total", "Total bytes written by the cgroup", "device"),
+		ioReads:          newCgroupDesc("io_reads_total", "Total read operations of the cgroup", "device"),
+		ioWrites:         newCgroupDesc("io_writes_total", "Total write operations of the cgroup", "device"),
+		pidsCurrent:      newCgroupDesc("pids_current", "Number of tasks in the cgroup"),
+		pidsMax:          newCgroupDesc("pids_max", "Maximum number of tasks of the cgroup, absent when unlimited"),
+	}
+	if collector.root == "" {
+		slog.Info("cgroup v2 is not mounted, disabling cgroup collector")
+	}
+	return collector, nil
+}
+
+func newCgroupDesc(name, help string, labels ...string) *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("system", "cgroup", name),
+		help,
+		append([]string{"cgroup", "container_id", "pod_uid"}, labels...),
+		nil,
+	)
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+type cgroupCollector struct {
+	config  *config.CgroupUsage
+	root    string
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+
+	cpuUsage         *prometheus.Desc
+	cpuUser          *prometheus.Desc
+	cpuSystem        *prometheus.Desc
+	cpuPeriods       *prometheus.Desc
+	cpuThrottled     *prometheus.Desc
+	cpuThrottledTime *prometheus.Desc
+	memoryCurrent    *prometheus.Desc
+	memoryMax        *prometheus.Desc
+	memoryEvents     *prometheus.Desc
+	ioReadBytes      *prometheus.Desc
+	ioWriteBytes     *prometheus.Desc
+	ioReads          *prometheus.Desc
+	ioWrites         *prometheus.Desc
+	pidsCurrent      *prometheus.Desc
+	pidsMax          *prometheus.Desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *cgroupCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting cgroup metrics")
+	if !c.config.Enabled {
+		slog.Warn("cgroup metrics are not enabled")
+		return
+	}
+	if c.root == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.GetTimeout())
+	defer cancel()
+
+	err := filepath.WalkDir(c.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// The cgroup was removed while walking
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(c.root, path)
+		if err != nil {
+			return err
+		}
+		cgroup := "/"
+		depth := 0
+		if rel != "." {
+			cgroup = "/" + filepath.ToSlash(rel)
+			depth = strings.Count(cgroup, "/")
+		}
+		if depth > c.config.GetDepth() {
+			return filepath.SkipDir
+		}
+		if c.selected(cgroup) {
+			c.collectCgroup(ch, path, cgroup)
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("failed to walk cgroup hierarchy", "error", err)
+	}
+}
+
+func (c *cgroupCollector) selected(cgroup string) bool {
+	for _, re := range c.exclude {
+		if re.MatchString(cgroup) {
+			return false
+		}
+	}
+	if len(c.include) == 0 {
+		return true
+	}
+	for _, re := range c.include {
+		if re.MatchString(cgroup) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cgroupCollector) collectCgroup(ch chan<- prometheus.Metric, dir, cgroup string) {
+	labels := []string{cgroup, containerID(cgroup), podUID(cgroup)}
+
+	if stat, err := readKeyedFile(filepath.Join(dir, "cpu.stat")); err == nil {
+		// cpu.stat reports times in microseconds
+		counters := map[string]*prometheus.Desc{
+			"usage_usec":     c.cpuUsage,
+			"user_usec":      c.cpuUser,
+			"system_usec":    c.cpuSystem,
+			"throttled_usec": c.cpuThrottledTime,
+		}
+		for key, desc := range counters {
+			if value, ok := stat[key]; ok {
+				ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value/1e6, labels...)
+			}
+		}
+		if value, ok := stat["nr_periods"]; ok {
+			ch <- prometheus.MustNewConstMetric(c.cpuPeriods, prometheus.CounterValue, value, labels...)
+		}
+		if value, ok := stat["nr_throttled"]; ok {
+			ch <- prometheus.MustNewConstMetric(c.cpuThrottled, prometheus.CounterValue, value, labels...)
+		}
+	}
+
+	if value, err := readCgroupValue(filepath.Join(dir, "memory.current")); err == nil {
+		ch <- prometheus.MustNewConstMetric(c.memoryCurrent, prometheus.GaugeValue, value, labels...)
+	}
+	if value, err := readCgroupValue(filepath.Join(dir, "memory.max")); err == nil {
+		ch <- prometheus.MustNewConstMetric(c.memoryMax, prometheus.GaugeValue, value, labels...)
+	}
+	if events, err := readKeyedFile(filepath.Join(dir, "memory.events")); err == nil {
+		for event, value := range events {
+			ch <- prometheus.MustNewConstMetric(c.memoryEvents, prometheus.CounterValue, value, append(labels, event)...)
+		}
+	}
+
+	if stats, err := readIOStat(filepath.Join(dir, "io.stat")); err == nil {
+		for device, stat := range stats {
+			deviceLabels := append(labels, device)
+			ch <- prometheus.MustNewConstMetric(c.ioReadBytes, prometheus.CounterValue, stat["rbytes"], deviceLabels...)
+			ch <- prometheus.MustNewConstMetric(c.ioWriteBytes, prometheus.CounterValue, stat["wbytes"], deviceLabels...)
+			ch <- prometheus.MustNewConstMetric(c.ioReads, prometheus.CounterValue, stat["rios"], deviceLabels...)
+			ch <- prometheus.MustNewConstMetric(c.ioWrites, prometheus.CounterValue, stat["wios"], deviceLabels...)
+		}
+	}
+
+	if value, err := readCgroupValue(filepath.Join(dir, "pids.current")); err == nil {
+		ch <- prometheus.MustNewConstMetric(c.pidsCurrent, prometheus.GaugeValue, value, labels...)
+	}
+	if value, err := readCgroupValue(filepath.Join(dir, "pids.max")); err == nil {
+		ch <- prometheus.MustNewConstMetric(c.pidsMax, prometheus.GaugeValue, value, labels...)
+	}
+}
+
+// containerID extracts the container ID from the last element of the cgroup path.
+func containerID(cgroup string) string {
+	if m := containerIDPattern.FindStringSubmatch(filepath.Base(cgroup)); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// podUID extracts the Kubernetes pod UID from a kubepods cgroup path.
+func podUID(cgroup string) string {
+	if !strings.Contains(cgroup, "kubepods") {
+		return ""
+	}
+	if m := podUIDPattern.FindStringSubmatch(cgroup); m != nil {
+		return strings.ReplaceAll(m[1], "_", "-")
+	}
+	return ""
+}
+
+// readCgroupValue reads a single value cgroup file. Unlimited values are
+// written as "max" and reported as an error so no series is exported.
+func readCgroupValue(path string) (float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return 0, fmt.Errorf("%s is unlimited", path)
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// readKeyedFile reads a flat keyed file with "key value" lines such as cpu.stat.
+func readKeyedFile(path string) (map[string]float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := make(map[string]float64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if value, err := strconv.ParseFloat(fields[1], 64); err == nil {
+			values[fields[0]] = value
+		}
+	}
+	return values, scanner.Err()
+}
+
+// readIOStat reads io.stat, a nested keyed file with lines like
+// "8:0 rbytes=1 wbytes=2 rios=3 wios=4 dbytes=0 dios=0" keyed by device.
+func readIOStat(path string) (map[string]map[string]float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stats := make(map[string]map[string]float64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		stat := make(map[string]float64, len(fields)-1)
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				stat[key] = v
+			}
+		}
+		if len(stat) == 0 {
+			// Not a nested keyed line, e.g. the "8:0 Read 4096" lines of
+			// the cgroup v1 blkio files
+			continue
+		}
+		stats[fields[0]] = stat
+	}
+	return stats, scanner.Err()
+}
+
+// Describe implements prometheus.Collector.
+func (c *cgroupCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.cpuUsage
+	ch <- c.cpuUser
+	ch <- c.cpuSystem
+	ch <- c.cpuPeriods
+	ch <- c.cpuThrottled
+	ch <- c.cpuThrottledTime
+	ch <- c.memoryCurrent
+	ch <- c.memoryMax
+	ch <- c.memoryEvents
+	ch <- c.ioReadBytes
+	ch <- c.ioWriteBytes
+	ch <- c.ioReads
+	ch <- c.ioWrites
+	ch <- c.pidsCurrent
+	ch <- c.pidsMax
+}
@@ -0,0 +1,141 @@
+package system
+
+import (
+	"maps"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	testContainerID = "3f4a5b6c7d8e9f0a1b2c3d4e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0c1d2e3f4a"
+	testPodUID      = "0d3e6f2a-1b4c-4d5e-8f9a-0b1c2d3e4f5a"
+	testPodSlice    = "0d3e6f2a_1b4c_4d5e_8f9a_0b1c2d3e4f5a"
+)
+
+func TestContainerIDAndPodUID(t *testing.T) {
+	tests := []struct {
+		name        string
+		cgroup      string
+		containerID string
+		podUID      string
+	}{
+		{
+			name:        "docker cgroupfs",
+			cgroup:      "/docker/" + testContainerID,
+			containerID: testContainerID,
+		},
+		{
+			name:        "docker systemd",
+			cgroup:      "/system.slice/docker-" + testContainerID + ".scope",
+			containerID: testContainerID,
+		},
+		{
+			name:        "podman systemd",
+			cgroup:      "/machine.slice/libpod-" + testContainerID + ".scope",
+			containerID: testContainerID,
+		},
+		{
+			name:        "containerd cgroupfs burstable",
+			cgroup:      "/kubepods/burstable/pod" + testPodUID + "/" + testContainerID,
+			containerID: testContainerID,
+			podUID:      testPodUID,
+		},
+		{
+			name:        "containerd systemd burstable",
+			cgroup:      "/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod" + testPodSlice + ".slice/cri-containerd-" + testContainerID + ".scope",
+			containerID: testContainerID,
+			podUID:      testPodUID,
+		},
+		{
+			name:        "cri-o cgroupfs besteffort",
+			cgroup:      "/kubepods/besteffort/pod" + testPodUID + "/" + testContainerID,
+			containerID: testContainerID,
+			podUID:      testPodUID,
+		},
+		{
+			name:        "cri-o systemd besteffort",
+			cgroup:      "/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod" + testPodSlice + ".slice/crio-" + testContainerID + ".scope",
+			containerID: testContainerID,
+			podUID:      testPodUID,
+		},
+		{
+			name:        "guaranteed pod",
+			cgroup:      "/kubepods.slice/kubepods-pod" + testPodSlice + ".slice",
+			containerID: "",
+			podUID:      testPodUID,
+		},
+		{
+			name:   "cri-o conmon",
+			cgroup: "/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod" + testPodSlice + ".slice/crio-conmon-" + testContainerID + ".scope",
+			podUID: testPodUID,
+		},
+		{
+			name:   "systemd service",
+			cgroup: "/system.slice/sshd.service",
+		},
+		{
+			name:   "pod outside kubepods",
+			cgroup: "/user.slice/pod" + testPodUID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := containerID(tt.cgroup); got != tt.containerID {
+				t.Errorf("containerID(%q) = %q, want %q", tt.cgroup, got, tt.containerID)
+			}
+			if got := podUID(tt.cgroup); got != tt.podUID {
+				t.Errorf("podUID(%q) = %q, want %q", tt.cgroup, got, tt.podUID)
+			}
+		})
+	}
+}
+
+func TestReadIOStat(t *testing.T) {
+	tests := []struct {
+		file string
+		want map[string]map[string]float64
+	}{
+		{
+			file: "v2",
+			want: map[string]map[string]float64{
+				"8:0":   {"rbytes": 1048576, "wbytes": 2097152, "rios": 256, "wios": 512, "dbytes": 0, "dios": 0},
+				"253:0": {"rbytes": 4096, "wbytes": 0, "rios": 1, "wios": 0, "dbytes": 0, "dios": 0},
+			},
+		},
+		{
+			// Kernels before 5.0 do not report discards
+			file: "v2-without-discard",
+			want: map[string]map[string]float64{
+				"8:16": {"rbytes": 90112, "wbytes": 0, "rios": 22, "wios": 0},
+			},
+		},
+		{
+			// io.cost and io.latency append their own keys
+			file: "v2-qos",
+			want: map[string]map[string]float64{
+				"8:0": {
+					"rbytes": 8192, "wbytes": 4096, "rios": 2, "wios": 1, "dbytes": 0, "dios": 0,
+					"cost.vrate": 100, "cost.usage": 123, "cost.wait": 0, "cost.indebt": 0, "cost.indelay": 0,
+				},
+				"8:16": {"rbytes": 512, "wbytes": 0, "rios": 1, "wios": 0, "dbytes": 0, "dios": 0, "depth": 1, "avg_lat": 150, "win": 100},
+			},
+		},
+		{
+			// The cgroup v1 blkio format has no nested keys, so no device
+			// is reported with zero counters
+			file: "v1-blkio",
+			want: map[string]map[string]float64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := readIOStat(filepath.Join("testdata", "iostat", tt.file))
+			if err != nil {
+				t.Fatalf("readIOStat() error = %v", err)
+			}
+			if !maps.EqualFunc(got, tt.want, maps.Equal) {
+				t.Errorf("readIOStat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package system
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*conntrackCollector)(nil)
+
+// conntrackStatFields are the per CPU conntrack counters worth alerting on.
+// The other columns of /proc/net/stat/nf_conntrack are either always zero on
+// current kernels or only useful when debugging the hash table.
+var conntrackStatFields = map[string]bool{
+	"found":          true,
+	"invalid":        true,
+	"insert":         true,
+	"insert_failed":  true,
+	"drop":           true,
+	"early_drop":     true,
+	"search_restart": true,
+	"clashres":       true,
+}
+
+// neighbourFamilies maps the address families of the neighbour tables to
+// their directory below /proc/sys/net.
+var neighbourFamilies = map[string]string{
+	"inet":  "ipv4",
+	"inet6": "ipv6",
+}
+
+func NewConntrackCollector(config *config.Usage) (prometheus.Collector, error) {
+	return &conntrackCollector{
+		config:       config,
+		entries:      prometheus.NewDesc("system_conntrack_entries", "Number of entries in the conntrack table", nil, nil),
+		entriesLimit: prometheus.NewDesc("system_conntrack_entries_limit", "Maximum number of entries in the conntrack table", nil, nil),
+		stat:         prometheus.NewDesc("system_conntrack_stat_total", "Conntrack event counter of the CPU from /proc/net/stat/nf_conntrack", []string{"cpu", "field"}, nil),
+		neighbours:   prometheus.NewDesc("system_neighbour_entries", "Number of entries in the ARP or NDP neighbour table by interface and state", []string{"device", "family", "state"}, nil),
+		neighbourGC:  prometheus.NewDesc("system_neighbour_gc_threshold", "Garbage collection threshold of the neighbour table, gc_thresh1 to gc_thresh3", []string{"family", "threshold"}, nil),
+	}, nil
+}
+
+type conntrackCollector struct {
+	config *config.Usage
+
+	entries      *prometheus.Desc
+	entriesLimit *prometheus.Desc
+	stat         *prometheus.Desc
+	neighbours   *prometheus.Desc
+	neighbourGC  *prometheus.Desc
+}
+
+// neighbour is an entry of the kernel neighbour table.
+type neighbour struct {
+	device string
+	family string
+	state  string
+}
+
+// Collect implements prometheus.Collector.
+func (c *conntrackCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting conntrack metrics")
+	if !c.config.Enabled {
+		slog.Warn("conntrack metrics are not enabled")
+		return
+	}
+
+	c.collectConntrack(ch)
+
+	neighbours, err := listNeighbours()
+	if err != nil && !errors.Is(err, errors.ErrUnsupported) {
+		slog.Error("failed to get neighbours", "error", err)
+	}
+	counts := make(map[neighbour]int)
+	for _, n := range neighbours {
+		counts[n]++
+	}
+	for n, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.neighbours, prometheus.GaugeValue, float64(count), n.device, n.family, n.state)
+	}
+	for family, dir := range neighbourFamilies {
+		for _, threshold := range []string{"1", "2", "3"} {
+			value, err := readUintFile(procPath("sys", "net", dir, "neigh", "default", "gc_thresh"+threshold))
+			if err != nil {
+				// ipv6 is missing when IPv6 is disabled
+				if !errors.Is(err, os.ErrNotExist) {
+					slog.Error("failed to get neighbour gc threshold", "family", family, "error", err)
+				}
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(c.neighbourGC, prometheus.GaugeValue, float64(value), family, threshold)
+		}
+	}
+}
+
+func (c *conntrackCollector) collectConntrack(ch chan<- prometheus.Metric) {
+	// The files only exist once the nf_conntrack module is loaded
+	count, err := readUintFile(procPath("sys", "net", "netfilter", "nf_conntrack_count"))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Error("failed to get conntrack entries", "error", err)
+		}
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(count))
+	if limit, err := readUintFile(procPath("sys", "net", "netfilter", "nf_conntrack_max")); err == nil {
+		ch <- prometheus.MustNewConstMetric(c.entriesLimit, prometheus.GaugeValue, float64(limit))
+	}
+
+	stats, err := readConntrackStat(procPath("net", "stat", "nf_conntrack"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Error("failed to get conntrack stats", "error", err)
+	}
+	for cpu, fields := range stats {
+		for field, value := range fields {
+			if !conntrackStatFields[field] {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(c.stat, prometheus.CounterValue, value, strconv.Itoa(cpu), field)
+		}
+	}
+}
+
+// readConntrackStat parses /proc/net/stat/nf_conntrack, a header of field
+// names followed by one row of hexadecimal counters per possible CPU, e.g.
+//
+//	entries  clashres found new invalid ignore delete ...
+//	00000000  00000000 00000000 00000000 0000002a 00000000 00000000 ...
+func readConntrackStat(path string) ([]map[string]float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("missing header in %s", path)
+	}
+	names := strings.Fields(scanner.Text())
+
+	var stats []map[string]float64
+	for scanner.Scan() {
+		values := strings.Fields(scanner.Text())
+		if len(values) != len(names) {
+			return nil, fmt.Errorf("malformed conntrack stats in %s", path)
+		}
+		fields := make(map[string]float64, len(names))
+		for i, name := range names {
+			value, err := strconv.ParseUint(values[i], 16, 64)
+			if err != nil {
+				return nil, fmt.Errorf("malformed value for %s in %s: %w", name, path, err)
+			}
+			fields[name] = float64(value)
+		}
+		stats = append(stats, fields)
+	}
+	return stats, scanner.Err()
+}
+
+// Describe implements prometheus.Collector.
+func (c *conntrackCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entries
+	ch <- c.entriesLimit
+	ch <- c.stat
+	ch <- c.neighbours
+	ch <- c.neighbourGC
+}
@@ -0,0 +1,59 @@
+package system
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReadConntrackStat(t *testing.T) {
+	tests := []struct {
+		file string
+		// fields is the number of fields of every row
+		fields int
+		// want holds some of the values of each row
+		want    []map[string]float64
+		wantErr bool
+	}{
+		{
+			file:   "stat",
+			fields: 17,
+			want: []map[string]float64{
+				{"entries": 42, "clashres": 0, "invalid": 16, "insert_failed": 0, "drop": 0, "search_restart": 3},
+				{"entries": 42, "clashres": 1, "invalid": 255, "insert_failed": 2, "drop": 2, "search_restart": 0},
+			},
+		},
+		{
+			// Kernels before 5.9 report searched instead of clashres
+			file:   "older-kernel",
+			fields: 17,
+			want: []map[string]float64{
+				{"entries": 7, "searched": 0, "invalid": 1},
+			},
+		},
+		{file: "short-row", wantErr: true},
+		{file: "malformed-value", wantErr: true},
+		{file: "empty", wantErr: true},
+		{file: "missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := readConntrackStat(filepath.Join("testdata", "conntrack", tt.file))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readConntrackStat() error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("readConntrackStat() returned %d rows, want %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if len(got[i]) != tt.fields {
+					t.Errorf("row %d has %d fields, want %d", i, len(got[i]), tt.fields)
+				}
+				for name, value := range want {
+					if v, ok := got[i][name]; !ok || v != value {
+						t.Errorf("row %d %s = %v, want %v", i, name, v, value)
+					}
+				}
+			}
+		})
+	}
+}
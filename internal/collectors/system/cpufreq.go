@@ -0,0 +1,109 @@
+package system
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*cpufreqCollector)(nil)
+
+func NewCPUFreqCollector(config *config.Usage) (prometheus.Collector, error) {
+	cpuLabels := []string{"cpu"}
+	return &cpufreqCollector{
+		config:           config,
+		frequency:        prometheus.NewDesc("system_cpu_frequency_hertz", "Current scaling frequency of the CPU", cpuLabels, nil),
+		frequencyMin:     prometheus.NewDesc("system_cpu_frequency_min_hertz", "Minimum scaling frequency of the CPU", cpuLabels, nil),
+		frequencyMax:     prometheus.NewDesc("system_cpu_frequency_max_hertz", "Maximum scaling frequency of the CPU", cpuLabels, nil),
+		coreThrottles:    prometheus.NewDesc("system_cpu_core_throttles_total", "Times the core was throttled because it was too hot", []string{"package", "core"}, nil),
+		packageThrottles: prometheus.NewDesc("system_cpu_package_throttles_total", "Times the package was throttled because it was too hot", []string{"package"}, nil),
+	}, nil
+}
+
+type cpufreqCollector struct {
+	config *config.Usage
+
+	frequency        *prometheus.Desc
+	frequencyMin     *prometheus.Desc
+	frequencyMax     *prometheus.Desc
+	coreThrottles    *prometheus.Desc
+	packageThrottles *prometheus.Desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *cpufreqCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting cpufreq metrics")
+	if !c.config.Enabled {
+		slog.Warn("cpufreq metrics are not enabled")
+		return
+	}
+
+	dir := sysPath("devices", "system", "cpu")
+	entries, err := listDirNames(dir)
+	if err != nil {
+		slog.Error("failed to get CPUs", "error", err)
+		return
+	}
+	// The package counter is repeated in every CPU of the package, and the
+	// core counter in every hyperthread of the core
+	seenCores := make(map[[2]string]bool)
+	seenPackages := make(map[string]bool)
+	for _, entry := range entries {
+		cpu, ok := strings.CutPrefix(entry, "cpu")
+		if !ok {
+			continue
+		}
+		if _, err := strconv.Atoi(cpu); err != nil {
+			continue
+		}
+		cpuDir := filepath.Join(dir, entry)
+
+		// Virtual machines usually have no cpufreq driver
+		for file, desc := range map[string]*prometheus.Desc{
+			"scaling_cur_freq": c.frequency,
+			"scaling_min_freq": c.frequencyMin,
+			"scaling_max_freq": c.frequencyMax,
+		} {
+			khz, err := readUintFile(filepath.Join(cpuDir, "cpufreq", file))
+			if err != nil {
+				if !errors.Is(err, os.ErrNotExist) {
+					slog.Debug("failed to get CPU frequency", "cpu", cpu, "file", file, "error", err)
+				}
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(khz)*1000, cpu)
+		}
+
+		// thermal_throttle only exists on Intel CPUs
+		throttleDir := filepath.Join(cpuDir, "thermal_throttle")
+		if _, err := os.Stat(throttleDir); err != nil {
+			continue
+		}
+		pkg := readSysfsString(filepath.Join(cpuDir, "topology", "physical_package_id"))
+		core := readSysfsString(filepath.Join(cpuDir, "topology", "core_id"))
+		if count, err := readUintFile(filepath.Join(throttleDir, "core_throttle_count")); err == nil && !seenCores[[2]string{pkg, core}] {
+			seenCores[[2]string{pkg, core}] = true
+			ch <- prometheus.MustNewConstMetric(c.coreThrottles, prometheus.CounterValue, float64(count), pkg, core)
+		}
+		if count, err := readUintFile(filepath.Join(throttleDir, "package_throttle_count")); err == nil && !seenPackages[pkg] {
+			seenPackages[pkg] = true
+			ch <- prometheus.MustNewConstMetric(c.packageThrottles, prometheus.CounterValue, float64(count), pkg)
+		}
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *cpufreqCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.frequency
+	ch <- c.frequencyMin
+	ch <- c.frequencyMax
+	ch <- c.coreThrottles
+	ch <- c.packageThrottles
+}
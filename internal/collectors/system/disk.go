@@ -0,0 +1,153 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shirou/gopsutil/v4/disk"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*diskCollector)(nil)
+
+var diskLabels = []string{"device", "mountpoint", "fstype"}
+
+var errDiskUsageStuck = errors.New("statfs is still pending from a previous scrape")
+
+// diskUsageTimeout bounds the statfs call of each mountpoint, so a hung
+// remote mount does not use up the scrape timeout of the mounts after it.
+const diskUsageTimeout = time.Second
+
+func NewDiskCollector(config *config.Usage) (prometheus.Collector, error) {
+	return &diskCollector{
+		config:      config,
+		statfs:      disk.UsageWithContext,
+		stuck:       make(map[string]struct{}),
+		sizeBytes:   newDiskDesc("size_bytes", "Filesystem size in bytes"),
+		freeBytes:   newDiskDesc("free_bytes", "Filesystem free space in bytes, including space reserved for root"),
+		usedBytes:   newDiskDesc("used_bytes", "Filesystem used space in bytes"),
+		availBytes:  newDiskDesc("avail_bytes", "Filesystem space available to non-root users in bytes"),
+		inodesTotal: newDiskDesc("inodes_total", "Filesystem total inodes"),
+		inodesFree:  newDiskDesc("inodes_free", "Filesystem free inodes"),
+		deviceError: newDiskDesc("device_error", "Whether an error or timeout occurred while getting filesystem statistics"),
+	}, nil
+}
+
+func newDiskDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName("system", "disk", name), help, diskLabels, nil)
+}
+
+type diskCollector struct {
+	config *config.Usage
+	statfs func(ctx context.Context, mountpoint string) (*disk.UsageStat, error)
+
+	// stuck tracks mountpoints whose statfs call has not returned yet, so a
+	// hung network filesystem does not pile up goroutines on every scrape.
+	stuckMu sync.Mutex
+	stuck   map[string]struct{}
+
+	sizeBytes   *prometheus.Desc
+	freeBytes   *prometheus.Desc
+	usedBytes   *prometheus.Desc
+	availBytes  *prometheus.Desc
+	inodesTotal *prometheus.Desc
+	inodesFree  *prometheus.Desc
+	deviceError *prometheus.Desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *diskCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting disk metrics")
+	if !c.config.Enabled {
+		slog.Warn("disk metrics are not enabled")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.GetTimeout())
+	defer cancel()
+
+	partitions, err := disk.PartitionsWithContext(ctx, false)
+	if err != nil {
+		slog.Error("failed to get disk partitions", "error", err)
+		return
+	}
+
+	// Track seen mountpoints to avoid duplicates from bind mounts
+	seenMountpoints := make(map[string]bool)
+	for _, partition := range partitions {
+		if seenMountpoints[partition.Mountpoint] {
+			continue
+		}
+		seenMountpoints[partition.Mountpoint] = true
+
+		labels := []string{partition.Device, partition.Mountpoint, partition.Fstype}
+		usage, err := c.mountUsage(ctx, partition.Mountpoint)
+		if err != nil {
+			slog.Warn("failed to get disk usage", "mountpoint", partition.Mountpoint, "error", err)
+			ch <- prometheus.MustNewConstMetric(c.deviceError, prometheus.GaugeValue, 1, labels...)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.deviceError, prometheus.GaugeValue, 0, labels...)
+		ch <- prometheus.MustNewConstMetric(c.sizeBytes, prometheus.GaugeValue, float64(usage.Total), labels...)
+		ch <- prometheus.MustNewConstMetric(c.freeBytes, prometheus.GaugeValue, float64(usage.Total-usage.Used), labels...)
+		ch <- prometheus.MustNewConstMetric(c.usedBytes, prometheus.GaugeValue, float64(usage.Used), labels...)
+		ch <- prometheus.MustNewConstMetric(c.availBytes, prometheus.GaugeValue, float64(usage.Free), labels...)
+		ch <- prometheus.MustNewConstMetric(c.inodesTotal, prometheus.GaugeValue, float64(usage.InodesTotal), labels...)
+		ch <- prometheus.MustNewConstMetric(c.inodesFree, prometheus.GaugeValue, float64(usage.InodesFree), labels...)
+	}
+}
+
+// mountUsage returns the usage of the mountpoint within diskUsageTimeout, or
+// the time left of the scrape when that is shorter.
+func (c *diskCollector) mountUsage(ctx context.Context, mountpoint string) (*disk.UsageStat, error) {
+	ctx, cancel := context.WithTimeout(ctx, diskUsageTimeout)
+	defer cancel()
+	return c.usage(ctx, mountpoint)
+}
+
+// usage runs statfs for the mountpoint in the background, because the call
+// ignores the context and may block forever on an unreachable remote mount.
+func (c *diskCollector) usage(ctx context.Context, mountpoint string) (*disk.UsageStat, error) {
+	c.stuckMu.Lock()
+	if _, ok := c.stuck[mountpoint]; ok {
+		c.stuckMu.Unlock()
+		return nil, errDiskUsageStuck
+	}
+	c.stuck[mountpoint] = struct{}{}
+	c.stuckMu.Unlock()
+
+	type result struct {
+		usage *disk.UsageStat
+		err   error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		usage, err := c.statfs(ctx, mountpoint)
+		c.stuckMu.Lock()
+		delete(c.stuck, mountpoint)
+		c.stuckMu.Unlock()
+		resultCh <- result{usage: usage, err: err}
+	}()
+
+	select {
+	case r := <-resultCh:
+		return r.usage, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *diskCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.sizeBytes
+	ch <- c.freeBytes
+	ch <- c.usedBytes
+	ch <- c.availBytes
+	ch <- c.inodesTotal
+	ch <- c.inodesFree
+	ch <- c.deviceError
+}
@@ -0,0 +1,74 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/disk"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+func TestDiskMountUsage(t *testing.T) {
+	hung := make(chan struct{})
+	defer close(hung)
+	collector, err := NewDiskCollector(&config.Usage{Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := collector.(*diskCollector)
+	c.statfs = func(_ context.Context, mountpoint string) (*disk.UsageStat, error) {
+		if mountpoint == "/mnt/nfs" {
+			// statfs ignores the context
+			<-hung
+		}
+		return &disk.UsageStat{Path: mountpoint, Total: 100}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*diskUsageTimeout)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.mountUsage(ctx, "/mnt/nfs"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("mountUsage(/mnt/nfs) error = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*diskUsageTimeout {
+		t.Errorf("hung mount took %v, want about %v", elapsed, diskUsageTimeout)
+	}
+	for _, mountpoint := range []string{"/", "/home"} {
+		if usage, err := c.mountUsage(ctx, mountpoint); err != nil || usage.Path != mountpoint {
+			t.Errorf("mountUsage(%s) = %v, %v after a hung mount", mountpoint, usage, err)
+		}
+	}
+
+	// The next scrape skips the mount until its statfs returns
+	if _, err := c.mountUsage(context.Background(), "/mnt/nfs"); !errors.Is(err, errDiskUsageStuck) {
+		t.Errorf("mountUsage(/mnt/nfs) error = %v, want %v", err, errDiskUsageStuck)
+	}
+}
+
+func TestDiskMountUsageBudget(t *testing.T) {
+	collector, err := NewDiskCollector(&config.Usage{Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := collector.(*diskCollector)
+	hung := make(chan struct{})
+	defer close(hung)
+	c.statfs = func(context.Context, string) (*disk.UsageStat, error) {
+		<-hung
+		return &disk.UsageStat{}, nil
+	}
+
+	// A scrape with less time left than diskUsageTimeout is not extended
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.mountUsage(ctx, "/mnt/nfs"); err == nil {
+		t.Error("mountUsage() succeeded on a hung mount")
+	}
+	if elapsed := time.Since(start); elapsed > diskUsageTimeout/2 {
+		t.Errorf("hung mount took %v, want the 100ms left of the scrape", elapsed)
+	}
+}
@@ -0,0 +1,157 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shirou/gopsutil/v4/process"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*fileCollector)(nil)
+
+func NewFileCollector(config *config.FileUsage) (prometheus.Collector, error) {
+	groups := make([]*processGroupMatcher, 0, len(config.Groups))
+	for _, group := range config.Groups {
+		matcher, err := newProcessGroupMatcher(group)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, matcher)
+	}
+	processLabels := []string{"pid", "name", "group"}
+	return &fileCollector{
+		config:         config,
+		groups:         groups,
+		allocated:      prometheus.NewDesc("system_file_allocated", "Number of allocated file handles in the system", nil, nil),
+		maximum:        prometheus.NewDesc("system_file_max", "Maximum number of file handles the system allows (fs.file-max)", nil, nil),
+		processOpenFDs: prometheus.NewDesc("system_file_process_open_fds", "Number of open file descriptors of the process", processLabels, nil),
+		processMaxFDs:  prometheus.NewDesc("system_file_process_max_fds", "Soft limit of open file descriptors of the process (RLIMIT_NOFILE)", processLabels, nil),
+	}, nil
+}
+
+type fileCollector struct {
+	config *config.FileUsage
+	groups []*processGroupMatcher
+
+	allocated      *prometheus.Desc
+	maximum        *prometheus.Desc
+	processOpenFDs *prometheus.Desc
+	processMaxFDs  *prometheus.Desc
+}
+
+type processFDs struct {
+	proc  *process.Process
+	group string
+	fds   int32
+}
+
+// Collect implements prometheus.Collector.
+func (c *fileCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting file metrics")
+	if !c.config.Enabled {
+		slog.Warn("file metrics are not enabled")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.GetTimeout())
+	defer cancel()
+
+	if allocated, maximum, err := readFileNr(); err != nil {
+		slog.Error("failed to get file handles", "error", err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.allocated, prometheus.GaugeValue, float64(allocated))
+		ch <- prometheus.MustNewConstMetric(c.maximum, prometheus.GaugeValue, float64(maximum))
+	}
+
+	processes, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		slog.Error("failed to get processes", "error", err)
+		return
+	}
+
+	candidates := make([]processFDs, 0, len(processes))
+	for _, proc := range processes {
+		if ctx.Err() != nil {
+			slog.Error("file collection timed out", "error", ctx.Err())
+			return
+		}
+		fds, err := proc.NumFDsWithContext(ctx)
+		if err != nil {
+			continue
+		}
+		candidate := processFDs{proc: proc, fds: fds}
+		for _, group := range c.groups {
+			if group.matches(ctx, proc) {
+				candidate.group = group.name
+				break
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	// Export every grouped process plus the top consumers
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].fds > candidates[j].fds
+	})
+	for i, candidate := range candidates {
+		if candidate.group == "" && (i >= c.config.GetTopN() || candidate.fds == 0) {
+			continue
+		}
+		c.collectProcess(ctx, ch, candidate)
+	}
+}
+
+func (c *fileCollector) collectProcess(ctx context.Context, ch chan<- prometheus.Metric, candidate processFDs) {
+	name, err := candidate.proc.NameWithContext(ctx)
+	if err != nil {
+		return
+	}
+	labels := []string{strconv.Itoa(int(candidate.proc.Pid)), name, candidate.group}
+	ch <- prometheus.MustNewConstMetric(c.processOpenFDs, prometheus.GaugeValue, float64(candidate.fds), labels...)
+
+	limits, err := candidate.proc.RlimitWithContext(ctx)
+	if err != nil {
+		return
+	}
+	for _, limit := range limits {
+		if limit.Resource == process.RLIMIT_NOFILE {
+			ch <- prometheus.MustNewConstMetric(c.processMaxFDs, prometheus.GaugeValue, float64(limit.Soft), labels...)
+			break
+		}
+	}
+}
+
+// readFileNr parses /proc/sys/fs/file-nr, which holds the number of allocated
+// file handles, the number of allocated but unused handles and the maximum.
+func readFileNr() (allocated, maximum uint64, err error) {
+	data, err := os.ReadFile(procPath("sys", "fs", "file-nr"))
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != 3 {
+		return 0, 0, fmt.Errorf("unexpected file-nr content %q", string(data))
+	}
+	if allocated, err = strconv.ParseUint(fields[0], 10, 64); err != nil {
+		return 0, 0, err
+	}
+	if maximum, err = strconv.ParseUint(fields[2], 10, 64); err != nil {
+		return 0, 0, err
+	}
+	return allocated, maximum, nil
+}
+
+// Describe implements prometheus.Collector.
+func (c *fileCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.allocated
+	ch <- c.maximum
+	ch <- c.processOpenFDs
+	ch <- c.processMaxFDs
+}
@@ -0,0 +1,128 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/load"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*hostCollector)(nil)
+
+func NewHostCollector(config *config.Usage) (prometheus.Collector, error) {
+	return &hostCollector{
+		config:          config,
+		load1:           prometheus.NewDesc("system_load1", "System load average over 1 minute", nil, nil),
+		load5:           prometheus.NewDesc("system_load5", "System load average over 5 minutes", nil, nil),
+		load15:          prometheus.NewDesc("system_load15", "System load average over 15 minutes", nil, nil),
+		bootTime:        prometheus.NewDesc("system_boot_time_seconds", "System boot time since unix epoch in seconds", nil, nil),
+		uptime:          prometheus.NewDesc("system_uptime_seconds", "System uptime in seconds", nil, nil),
+		users:           prometheus.NewDesc("system_users", "Number of logged in user sessions", nil, nil),
+		contextSwitches: prometheus.NewDesc("system_context_switches_total", "Total number of context switches", nil, nil),
+		forks:           prometheus.NewDesc("system_forks_total", "Total number of forks", nil, nil),
+		procsRunning:    prometheus.NewDesc("system_procs_running", "Number of processes in runnable state", nil, nil),
+		procsBlocked:    prometheus.NewDesc("system_procs_blocked", "Number of processes blocked waiting for I/O", nil, nil),
+		info: prometheus.NewDesc(
+			"system_host_info",
+			"Host information, value is always 1",
+			[]string{"hostname", "os", "platform", "platform_family", "platform_version", "kernel_version", "kernel_arch", "virtualization_system", "virtualization_role"},
+			nil,
+		),
+	}, nil
+}
+
+type hostCollector struct {
+	config *config.Usage
+
+	load1           *prometheus.Desc
+	load5           *prometheus.Desc
+	load15          *prometheus.Desc
+	bootTime        *prometheus.Desc
+	uptime          *prometheus.Desc
+	users           *prometheus.Desc
+	contextSwitches *prometheus.Desc
+	forks           *prometheus.Desc
+	procsRunning    *prometheus.Desc
+	procsBlocked    *prometheus.Desc
+	info            *prometheus.Desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *hostCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting host metrics")
+	if !c.config.Enabled {
+		slog.Warn("host metrics are not enabled")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.GetTimeout())
+	defer cancel()
+
+	if avg, err := load.AvgWithContext(ctx); err != nil {
+		slog.Error("failed to get load average", "error", err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.load1, prometheus.GaugeValue, avg.Load1)
+		ch <- prometheus.MustNewConstMetric(c.load5, prometheus.GaugeValue, avg.Load5)
+		ch <- prometheus.MustNewConstMetric(c.load15, prometheus.GaugeValue, avg.Load15)
+	}
+
+	if misc, err := load.MiscWithContext(ctx); err != nil {
+		slog.Error("failed to get process statistics", "error", err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.contextSwitches, prometheus.CounterValue, float64(misc.Ctxt))
+		ch <- prometheus.MustNewConstMetric(c.forks, prometheus.CounterValue, float64(misc.ProcsCreated))
+		ch <- prometheus.MustNewConstMetric(c.procsRunning, prometheus.GaugeValue, float64(misc.ProcsRunning))
+		ch <- prometheus.MustNewConstMetric(c.procsBlocked, prometheus.GaugeValue, float64(misc.ProcsBlocked))
+	}
+
+	// Minimal images and containers often have no utmp at all, which means
+	// nobody is logged in rather than a failure.
+	if users, err := host.UsersWithContext(ctx); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Error("failed to get users", "error", err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.users, prometheus.GaugeValue, float64(len(users)))
+	}
+
+	info, err := host.InfoWithContext(ctx)
+	if err != nil {
+		slog.Error("failed to get host info", "error", err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.bootTime, prometheus.GaugeValue, float64(info.BootTime))
+	// Compute the uptime from the boot time instead of info.Uptime, which is
+	// read separately, so system_boot_time_seconds plus the uptime is always
+	// the scrape time.
+	ch <- prometheus.MustNewConstMetric(c.uptime, prometheus.GaugeValue, time.Since(time.Unix(int64(info.BootTime), 0)).Seconds())
+	ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1,
+		info.Hostname,
+		info.OS,
+		info.Platform,
+		info.PlatformFamily,
+		info.PlatformVersion,
+		info.KernelVersion,
+		info.KernelArch,
+		info.VirtualizationSystem,
+		info.VirtualizationRole,
+	)
+}
+
+// Describe implements prometheus.Collector.
+func (c *hostCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.load1
+	ch <- c.load5
+	ch <- c.load15
+	ch <- c.bootTime
+	ch <- c.uptime
+	ch <- c.users
+	ch <- c.contextSwitches
+	ch <- c.forks
+	ch <- c.procsRunning
+	ch <- c.procsBlocked
+	ch <- c.info
+}
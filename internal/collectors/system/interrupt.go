@@ -0,0 +1,194 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*interruptCollector)(nil)
+
+// irqQueuePattern matches the per queue suffix of a device name that has no
+// dash before it, e.g. nvme0q3 or mlx5_comp12.
+var irqQueuePattern = regexp.MustCompile(`^(nvme\d+q|.+_comp)\d+$`)
+
+func NewInterruptCollector(config *config.Usage) (prometheus.Collector, error) {
+	return &interruptCollector{
+		config:     config,
+		interrupts: prometheus.NewDesc("system_interrupts_total", "Interrupts serviced by the CPU, summed over the queues of a device", []string{"cpu", "device"}, nil),
+		softirqs:   prometheus.NewDesc("system_softirqs_total", "Softirqs serviced by the CPU", []string{"cpu", "type"}, nil),
+	}, nil
+}
+
+type interruptCollector struct {
+	config *config.Usage
+
+	interrupts *prometheus.Desc
+	softirqs   *prometheus.Desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *interruptCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting interrupt metrics")
+	if !c.config.Enabled {
+		slog.Warn("interrupt metrics are not enabled")
+		return
+	}
+
+	cpus, interrupts, err := readInterrupts(procPath("interrupts"))
+	if err != nil {
+		slog.Error("failed to get interrupts", "error", err)
+	}
+	for device, counts := range interrupts {
+		for i, count := range counts {
+			ch <- prometheus.MustNewConstMetric(c.interrupts, prometheus.CounterValue, count, cpus[i], device)
+		}
+	}
+
+	cpus, softirqs, err := readSoftirqs(procPath("softirqs"))
+	if err != nil {
+		slog.Error("failed to get softirqs", "error", err)
+	}
+	for kind, counts := range softirqs {
+		for i, count := range counts {
+			ch <- prometheus.MustNewConstMetric(c.softirqs, prometheus.CounterValue, count, cpus[i], kind)
+		}
+	}
+}
+
+// readInterrupts parses /proc/interrupts into per CPU counts keyed by device,
+// along with the CPUs of the columns. Numbered interrupts are keyed by their
+// aggregated device name, so the queues of a NIC or an NVMe drive add up to
+// one series per CPU. The architecture specific interrupts such as LOC or
+// NMI are keyed by their name.
+func readInterrupts(path string) ([]string, map[string][]float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	if !scanner.Scan() {
+		return nil, nil, fmt.Errorf("missing header in %s", path)
+	}
+	cpuNames := cpuColumns(scanner.Text())
+	cpus := len(cpuNames)
+
+	interrupts := make(map[string][]float64)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		irq := strings.TrimSuffix(fields[0], ":")
+		if irq == "ERR" || irq == "MIS" {
+			// A single total instead of per CPU counts
+			continue
+		}
+		n := min(cpus, len(fields)-1)
+		counts := make([]float64, 0, n)
+		for _, field := range fields[1 : n+1] {
+			count, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				break
+			}
+			counts = append(counts, count)
+		}
+		if len(counts) < cpus {
+			continue
+		}
+
+		device := irq
+		if _, err := strconv.Atoi(irq); err == nil {
+			if len(fields) <= cpus+1 {
+				continue
+			}
+			device = aggregateDevice(fields[len(fields)-1])
+		}
+		if total, ok := interrupts[device]; ok {
+			for i := range total {
+				total[i] += counts[i]
+			}
+			continue
+		}
+		interrupts[device] = counts
+	}
+	return cpuNames, interrupts, scanner.Err()
+}
+
+// cpuColumns returns the CPU numbers of a header line such as
+// "CPU0 CPU1 CPU3". Offline CPUs have no column.
+func cpuColumns(header string) []string {
+	fields := strings.Fields(header)
+	cpus := make([]string, len(fields))
+	for i, field := range fields {
+		cpus[i] = strings.TrimPrefix(field, "CPU")
+	}
+	return cpus
+}
+
+// aggregateDevice strips the queue from an interrupt handler name, e.g.
+// eth0-TxRx-3 becomes eth0, virtio0-input.0 becomes virtio0 and nvme0q3
+// becomes nvme0.
+func aggregateDevice(name string) string {
+	name, _, _ = strings.Cut(name, "@")
+	if i := strings.IndexByte(name, '-'); i > 0 {
+		name = name[:i]
+	}
+	if m := irqQueuePattern.FindStringSubmatch(name); m != nil {
+		name = strings.TrimSuffix(m[1], "q")
+	}
+	return name
+}
+
+// readSoftirqs parses /proc/softirqs into per CPU counts keyed by softirq
+// type, e.g. NET_RX.
+func readSoftirqs(path string) ([]string, map[string][]float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	if !scanner.Scan() {
+		return nil, nil, fmt.Errorf("missing header in %s", path)
+	}
+	cpuNames := cpuColumns(scanner.Text())
+	cpus := len(cpuNames)
+
+	softirqs := make(map[string][]float64)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != cpus+1 {
+			continue
+		}
+		counts := make([]float64, 0, cpus)
+		for _, field := range fields[1:] {
+			count, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, nil, fmt.Errorf("malformed count %q in %s: %w", field, path, err)
+			}
+			counts = append(counts, count)
+		}
+		softirqs[strings.TrimSuffix(fields[0], ":")] = counts
+	}
+	return cpuNames, softirqs, scanner.Err()
+}
+
+// Describe implements prometheus.Collector.
+func (c *interruptCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.interrupts
+	ch <- c.softirqs
+}
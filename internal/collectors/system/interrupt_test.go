@@ -0,0 +1,86 @@
+package system
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReadInterrupts(t *testing.T) {
+	tests := []struct {
+		file       string
+		cpus       []string
+		interrupts map[string][]float64
+		wantErr    bool
+	}{
+		{
+			// CPU2 is offline and has no column
+			file: "x86",
+			cpus: []string{"0", "1", "3"},
+			interrupts: map[string][]float64{
+				"timer":        {35, 0, 0},
+				"rtc0":         {0, 0, 1},
+				"acpi":         {0, 12, 0},
+				"nvme0":        {1100, 500, 400},
+				"eth0":         {16, 22, 33},
+				"mlx5_async17": {7, 0, 0},
+				"mlx5_comp":    {0, 50, 60},
+				"NMI":          {4, 5, 6},
+				"LOC":          {123456, 234567, 345678},
+				"RES":          {11, 22, 33},
+			},
+		},
+		{
+			// ERR has as many columns as there are CPUs, but is still a total
+			file: "single-cpu",
+			cpus: []string{"0"},
+			interrupts: map[string][]float64{
+				"i8042":   {9},
+				"virtio0": {42},
+				"LOC":     {100},
+			},
+		},
+		{
+			file:       "truncated",
+			cpus:       []string{"0", "1"},
+			interrupts: map[string][]float64{},
+		},
+		{
+			file:    "empty",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			cpus, interrupts, err := readInterrupts(filepath.Join("testdata", "interrupts", tt.file))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readInterrupts() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !slices.Equal(cpus, tt.cpus) {
+				t.Errorf("cpus = %q, want %q", cpus, tt.cpus)
+			}
+			if !maps.EqualFunc(interrupts, tt.interrupts, slices.Equal) {
+				t.Errorf("interrupts = %v, want %v", interrupts, tt.interrupts)
+			}
+		})
+	}
+}
+
+func TestAggregateDevice(t *testing.T) {
+	tests := map[string]string{
+		"eth0-TxRx-3":                   "eth0",
+		"eth0":                          "eth0",
+		"nvme0q3":                       "nvme0",
+		"nvme12q0":                      "nvme12",
+		"mlx5_comp12@pci:0000:3b:00.0":  "mlx5_comp",
+		"mlx5_async17@pci:0000:3b:00.0": "mlx5_async17",
+		"virtio0-input.0":               "virtio0",
+		"i8042":                         "i8042",
+	}
+	for name, want := range tests {
+		if got := aggregateDevice(name); got != want {
+			t.Errorf("aggregateDevice(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package system
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*linkCollector)(nil)
+
+func NewLinkCollector(config *config.Usage) (prometheus.Collector, error) {
+	deviceLabels := []string{"device"}
+	slaveLabels := []string{"master", "slave"}
+	return &linkCollector{
+		config:         config,
+		operState:      prometheus.NewDesc("system_network_oper_state", "Operational state of the network interface, e.g. up, down or dormant", []string{"device", "state"}, nil),
+		carrier:        prometheus.NewDesc("system_network_carrier", "Whether the network interface has a carrier", deviceLabels, nil),
+		carrierChanges: prometheus.NewDesc("system_network_carrier_changes_total", "Times the carrier of the network interface went up or down", deviceLabels, nil),
+		speed:          prometheus.NewDesc("system_network_speed_bytes_per_second", "Negotiated speed of the network interface", deviceLabels, nil),
+		duplex:         prometheus.NewDesc("system_network_duplex", "Negotiated duplex of the network interface, full or half", []string{"device", "duplex"}, nil),
+		masterSlaves:   prometheus.NewDesc("system_network_master_slaves", "Number of slaves of the bond or team", []string{"master", "kind"}, nil),
+		masterSlavesUp: prometheus.NewDesc("system_network_master_slaves_up", "Number of slaves of the bond or team whose link is up", []string{"master", "kind"}, nil),
+		activeSlave:    prometheus.NewDesc("system_network_bond_active_slave", "Slave carrying the traffic of an active-backup bond", slaveLabels, nil),
+		slaveUp:        prometheus.NewDesc("system_network_slave_up", "Whether the link of the bond or team slave is up, from its MII status for bonds and its carrier for teams", slaveLabels, nil),
+		slaveFailures:  prometheus.NewDesc("system_network_bond_slave_link_failures_total", "Times the bond detected a link failure of the slave", slaveLabels, nil),
+	}, nil
+}
+
+type linkCollector struct {
+	config *config.Usage
+
+	operState      *prometheus.Desc
+	carrier        *prometheus.Desc
+	carrierChanges *prometheus.Desc
+	speed          *prometheus.Desc
+	duplex         *prometheus.Desc
+	masterSlaves   *prometheus.Desc
+	masterSlavesUp *prometheus.Desc
+	activeSlave    *prometheus.Desc
+	slaveUp        *prometheus.Desc
+	slaveFailures  *prometheus.Desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *linkCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting link metrics")
+	if !c.config.Enabled {
+		slog.Warn("link metrics are not enabled")
+		return
+	}
+
+	dir := sysPath("class", "net")
+	devices, err := listDirNames(dir)
+	if err != nil {
+		slog.Error("failed to get network interfaces", "error", err)
+		return
+	}
+	for _, device := range devices {
+		deviceDir := filepath.Join(dir, device)
+		if state := readSysfsString(filepath.Join(deviceDir, "operstate")); state != "" {
+			ch <- prometheus.MustNewConstMetric(c.operState, prometheus.GaugeValue, 1, device, state)
+		}
+		// carrier, speed and duplex cannot be read while the interface is
+		// administratively down, and virtual interfaces have no speed
+		if value, err := readSysfsFloat(filepath.Join(deviceDir, "carrier")); err == nil {
+			ch <- prometheus.MustNewConstMetric(c.carrier, prometheus.GaugeValue, value, device)
+		}
+		if value, err := readSysfsFloat(filepath.Join(deviceDir, "carrier_changes")); err == nil {
+			ch <- prometheus.MustNewConstMetric(c.carrierChanges, prometheus.CounterValue, value, device)
+		}
+		if mbps, err := readSysfsFloat(filepath.Join(deviceDir, "speed")); err == nil && mbps > 0 {
+			ch <- prometheus.MustNewConstMetric(c.speed, prometheus.GaugeValue, mbps*1000*1000/8, device)
+		}
+		if duplex := readSysfsString(filepath.Join(deviceDir, "duplex")); duplex == "full" || duplex == "half" {
+			ch <- prometheus.MustNewConstMetric(c.duplex, prometheus.GaugeValue, 1, device, duplex)
+		}
+
+		switch {
+		case isDir(filepath.Join(deviceDir, "bonding")):
+			c.collectBond(ch, dir, device)
+		case deviceType(deviceDir) == "team":
+			c.collectTeam(ch, dir, device)
+		}
+	}
+}
+
+// collectBond reports the slaves of a bonding master from its bonding
+// directory and the bonding_slave directories of the slaves.
+func (c *linkCollector) collectBond(ch chan<- prometheus.Metric, dir, master string) {
+	bondingDir := filepath.Join(dir, master, "bonding")
+	slaves := strings.Fields(readSysfsString(filepath.Join(bondingDir, "slaves")))
+	up := 0
+	for _, slave := range slaves {
+		slaveDir := filepath.Join(dir, slave, "bonding_slave")
+		mii := readSysfsString(filepath.Join(slaveDir, "mii_status"))
+		if mii == "up" {
+			up++
+		}
+		ch <- prometheus.MustNewConstMetric(c.slaveUp, prometheus.GaugeValue, boolToFloat(mii == "up"), master, slave)
+		if value, err := readSysfsFloat(filepath.Join(slaveDir, "link_failure_count")); err == nil {
+			ch <- prometheus.MustNewConstMetric(c.slaveFailures, prometheus.CounterValue, value, master, slave)
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(c.masterSlaves, prometheus.GaugeValue, float64(len(slaves)), master, "bond")
+	ch <- prometheus.MustNewConstMetric(c.masterSlavesUp, prometheus.GaugeValue, float64(up), master, "bond")
+
+	// active_slave is empty outside of the active-backup, tlb and alb modes
+	if active := readSysfsString(filepath.Join(bondingDir, "active_slave")); active != "" {
+		ch <- prometheus.MustNewConstMetric(c.activeSlave, prometheus.GaugeValue, 1, master, active)
+	}
+}
+
+// collectTeam reports the slaves of a team master. The team driver leaves
+// the port state to teamd, so the slaves are found through the lower_* links
+// of the master and are up when they have a carrier. The active port of an
+// activebackup team is only known to teamd and is not reported.
+func (c *linkCollector) collectTeam(ch chan<- prometheus.Metric, dir, master string) {
+	entries, err := listDirNames(filepath.Join(dir, master))
+	if err != nil {
+		slog.Error("failed to get team slaves", "master", master, "error", err)
+		return
+	}
+	slaves, up := 0, 0
+	for _, entry := range entries {
+		slave, ok := strings.CutPrefix(entry, "lower_")
+		if !ok {
+			continue
+		}
+		slaves++
+		carrier, err := readSysfsFloat(filepath.Join(dir, slave, "carrier"))
+		if err == nil && carrier == 1 {
+			up++
+		}
+		ch <- prometheus.MustNewConstMetric(c.slaveUp, prometheus.GaugeValue, boolToFloat(err == nil && carrier == 1), master, slave)
+	}
+	ch <- prometheus.MustNewConstMetric(c.masterSlaves, prometheus.GaugeValue, float64(slaves), master, "team")
+	ch <- prometheus.MustNewConstMetric(c.masterSlavesUp, prometheus.GaugeValue, float64(up), master, "team")
+}
+
+// deviceType returns the DEVTYPE of a network interface from its uevent
+// file, e.g. bond, team or vlan. Physical interfaces have none.
+func deviceType(deviceDir string) string {
+	for _, line := range strings.Split(readSysfsString(filepath.Join(deviceDir, "uevent")), "\n") {
+		if value, ok := strings.CutPrefix(line, "DEVTYPE="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// Describe implements prometheus.Collector.
+func (c *linkCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.operState
+	ch <- c.carrier
+	ch <- c.carrierChanges
+	ch <- c.speed
+	ch <- c.duplex
+	ch <- c.masterSlaves
+	ch <- c.masterSlavesUp
+	ch <- c.activeSlave
+	ch <- c.slaveUp
+	ch <- c.slaveFailures
+}
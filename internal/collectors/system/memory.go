@@ -0,0 +1,111 @@
+package system
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shirou/gopsutil/v4/mem"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*memoryCollector)(nil)
+
+func NewMemoryCollector(config *config.Usage) (prometheus.Collector, error) {
+	return &memoryCollector{
+		config:         config,
+		totalBytes:     newMemoryDesc("memory_total_bytes", "System memory total in bytes"),
+		availableBytes: newMemoryDesc("memory_available_bytes", "System memory available for programs in bytes"),
+		usedBytes:      newMemoryDesc("memory_used_bytes", "System memory used by programs in bytes"),
+		freeBytes:      newMemoryDesc("memory_free_bytes", "System memory free in bytes"),
+		buffersBytes:   newMemoryDesc("memory_buffers_bytes", "System memory used by kernel buffers in bytes"),
+		cachedBytes:    newMemoryDesc("memory_cached_bytes", "System memory used by the page cache in bytes"),
+		slabBytes:      newMemoryDesc("memory_slab_bytes", "System memory used by the kernel slab allocator in bytes"),
+		sharedBytes:    newMemoryDesc("memory_shared_bytes", "System memory used by shared memory and tmpfs in bytes"),
+		dirtyBytes:     newMemoryDesc("memory_dirty_bytes", "System memory waiting to be written back to disk in bytes"),
+		writebackBytes: newMemoryDesc("memory_writeback_bytes", "System memory actively being written back to disk in bytes"),
+		swapTotalBytes: newMemoryDesc("swap_total_bytes", "System swap total in bytes"),
+		swapUsedBytes:  newMemoryDesc("swap_used_bytes", "System swap used in bytes"),
+		swapInBytes:    newMemoryDesc("swap_in_bytes_total", "System bytes swapped in from disk"),
+		swapOutBytes:   newMemoryDesc("swap_out_bytes_total", "System bytes swapped out to disk"),
+	}, nil
+}
+
+func newMemoryDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName("system", "", name), help, nil, nil)
+}
+
+type memoryCollector struct {
+	config *config.Usage
+
+	totalBytes     *prometheus.Desc
+	availableBytes *prometheus.Desc
+	usedBytes      *prometheus.Desc
+	freeBytes      *prometheus.Desc
+	buffersBytes   *prometheus.Desc
+	cachedBytes    *prometheus.Desc
+	slabBytes      *prometheus.Desc
+	sharedBytes    *prometheus.Desc
+	dirtyBytes     *prometheus.Desc
+	writebackBytes *prometheus.Desc
+	swapTotalBytes *prometheus.Desc
+	swapUsedBytes  *prometheus.Desc
+	swapInBytes    *prometheus.Desc
+	swapOutBytes   *prometheus.Desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *memoryCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting memory metrics")
+	if !c.config.Enabled {
+		slog.Warn("memory metrics are not enabled")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.GetTimeout())
+	defer cancel()
+
+	vmem, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		slog.Error("failed to get virtual memory", "error", err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.totalBytes, prometheus.GaugeValue, float64(vmem.Total))
+		ch <- prometheus.MustNewConstMetric(c.availableBytes, prometheus.GaugeValue, float64(vmem.Available))
+		ch <- prometheus.MustNewConstMetric(c.usedBytes, prometheus.GaugeValue, float64(vmem.Used))
+		ch <- prometheus.MustNewConstMetric(c.freeBytes, prometheus.GaugeValue, float64(vmem.Free))
+		ch <- prometheus.MustNewConstMetric(c.buffersBytes, prometheus.GaugeValue, float64(vmem.Buffers))
+		ch <- prometheus.MustNewConstMetric(c.cachedBytes, prometheus.GaugeValue, float64(vmem.Cached))
+		ch <- prometheus.MustNewConstMetric(c.slabBytes, prometheus.GaugeValue, float64(vmem.Slab))
+		ch <- prometheus.MustNewConstMetric(c.sharedBytes, prometheus.GaugeValue, float64(vmem.Shared))
+		ch <- prometheus.MustNewConstMetric(c.dirtyBytes, prometheus.GaugeValue, float64(vmem.Dirty))
+		ch <- prometheus.MustNewConstMetric(c.writebackBytes, prometheus.GaugeValue, float64(vmem.WriteBack))
+	}
+
+	swap, err := mem.SwapMemoryWithContext(ctx)
+	if err != nil {
+		slog.Error("failed to get swap memory", "error", err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.swapTotalBytes, prometheus.GaugeValue, float64(swap.Total))
+	ch <- prometheus.MustNewConstMetric(c.swapUsedBytes, prometheus.GaugeValue, float64(swap.Used))
+	ch <- prometheus.MustNewConstMetric(c.swapInBytes, prometheus.CounterValue, float64(swap.Sin))
+	ch <- prometheus.MustNewConstMetric(c.swapOutBytes, prometheus.CounterValue, float64(swap.Sout))
+}
+
+// Describe implements prometheus.Collector.
+func (c *memoryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.totalBytes
+	ch <- c.availableBytes
+	ch <- c.usedBytes
+	ch <- c.freeBytes
+	ch <- c.buffersBytes
+	ch <- c.cachedBytes
+	ch <- c.slabBytes
+	ch <- c.sharedBytes
+	ch <- c.dirtyBytes
+	ch <- c.writebackBytes
+	ch <- c.swapTotalBytes
+	ch <- c.swapUsedBytes
+	ch <- c.swapInBytes
+	ch <- c.swapOutBytes
+}
@@ -0,0 +1,72 @@
+package system
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"syscall"
+)
+
+// ndmsgLen is the size of struct ndmsg from linux/neighbour.h.
+const ndmsgLen = 12
+
+// neighbourStates maps the NUD_* states from linux/neighbour.h to names.
+var neighbourStates = map[uint16]string{
+	0x00: "none",
+	0x01: "incomplete",
+	0x02: "reachable",
+	0x04: "stale",
+	0x08: "delay",
+	0x10: "probe",
+	0x20: "failed",
+	0x40: "noarp",
+	0x80: "permanent",
+}
+
+// listNeighbours dumps the IPv4 and IPv6 neighbour tables over rtnetlink.
+// Unlike /proc/net/arp it covers IPv6 and reports the NUD state of the
+// entries, as "ip neigh" does.
+func listNeighbours() ([]neighbour, error) {
+	data, err := syscall.NetlinkRIB(syscall.RTM_GETNEIGH, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dump neighbours: %w", err)
+	}
+	messages, err := syscall.ParseNetlinkMessage(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse netlink response: %w", err)
+	}
+
+	devices := make(map[int]string)
+	var neighbours []neighbour
+	for _, m := range messages {
+		if m.Header.Type != syscall.RTM_NEWNEIGH || len(m.Data) < ndmsgLen {
+			continue
+		}
+		var family string
+		switch m.Data[0] {
+		case syscall.AF_INET:
+			family = "inet"
+		case syscall.AF_INET6:
+			family = "inet6"
+		default:
+			continue
+		}
+		index := int(int32(binary.NativeEndian.Uint32(m.Data[4:8])))
+		device, ok := devices[index]
+		if !ok {
+			device = strconv.Itoa(index)
+			if iface, err := net.InterfaceByIndex(index); err == nil {
+				device = iface.Name
+			}
+			devices[index] = device
+		}
+		state := binary.NativeEndian.Uint16(m.Data[8:10])
+		name, ok := neighbourStates[state]
+		if !ok {
+			name = strconv.Itoa(int(state))
+		}
+		neighbours = append(neighbours, neighbour{device: device, family: family, state: name})
+	}
+	return neighbours, nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package system
+
+import "errors"
+
+// listNeighbours is only implemented on Linux.
+func listNeighbours() ([]neighbour, error) {
+	return nil, errors.ErrUnsupported
+}
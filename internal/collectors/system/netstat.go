@@ -0,0 +1,194 @@
+package system
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*netstatCollector)(nil)
+
+// defaultNetstatFields are exported when no fields are configured. They
+// cover the drops, errors and retransmissions worth alerting on.
+var defaultNetstatFields = []string{
+	`^Ip6?_(Forwarding|ForwDatagrams|OutForwDatagrams|InReceives|InDiscards|OutDiscards|InNoRoutes|OutNoRoutes|InHdrErrors|InAddrErrors)$`,
+	`^Icmp6?_(InMsgs|OutMsgs|InErrors|OutErrors)$`,
+	`^Tcp_(ActiveOpens|PassiveOpens|AttemptFails|EstabResets|CurrEstab|InSegs|OutSegs|RetransSegs|InErrs|OutRsts|InCsumErrors)$`,
+	`^TcpExt_(ListenOverflows|ListenDrops|SyncookiesSent|SyncookiesRecv|SyncookiesFailed|TCPSynRetrans|TCPTimeouts|TCPBacklogDrop|PruneCalled|TCPOFOQueue|TCPAbortOnMemory|TCPAbortOnTimeout)$`,
+	`^(Udp|Udp6|UdpLite|UdpLite6)_(InDatagrams|OutDatagrams|NoPorts|InErrors|RcvbufErrors|SndbufErrors|InCsumErrors)$`,
+}
+
+// netstatGauges are the fields holding a setting or a current value rather
+// than an event count.
+var netstatGauges = map[string]bool{
+	"Ip_Forwarding":    true,
+	"Ip_DefaultTTL":    true,
+	"Tcp_RtoAlgorithm": true,
+	"Tcp_RtoMin":       true,
+	"Tcp_RtoMax":       true,
+	"Tcp_MaxConn":      true,
+	"Tcp_CurrEstab":    true,
+}
+
+// snmp6Pattern splits a /proc/net/snmp6 key such as Udp6InDatagrams into its
+// protocol and field.
+var snmp6Pattern = regexp.MustCompile(`^([A-Za-z]+6)(.+)$`)
+
+func NewNetstatCollector(config *config.NetstatUsage) (prometheus.Collector, error) {
+	fields := config.Fields
+	if len(fields) == 0 {
+		fields = defaultNetstatFields
+	}
+	patterns, err := compilePatterns(fields)
+	if err != nil {
+		return nil, fmt.Errorf("invalid netstat field pattern: %w", err)
+	}
+	labels := []string{"protocol", "field"}
+	return &netstatCollector{
+		config:   config,
+		patterns: patterns,
+		counter:  prometheus.NewDesc("system_netstat_total", "Kernel protocol counter from /proc/net/snmp, snmp6 or netstat", labels, nil),
+		gauge:    prometheus.NewDesc("system_netstat", "Kernel protocol setting or current value from /proc/net/snmp", labels, nil),
+	}, nil
+}
+
+type netstatCollector struct {
+	config   *config.NetstatUsage
+	patterns []*regexp.Regexp
+
+	counter *prometheus.Desc
+	gauge   *prometheus.Desc
+}
+
+// netstatField is one value of the kernel protocol statistics.
+type netstatField struct {
+	protocol string
+	field    string
+	value    float64
+}
+
+// Collect implements prometheus.Collector.
+func (c *netstatCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting netstat metrics")
+	if !c.config.Enabled {
+		slog.Warn("netstat metrics are not enabled")
+		return
+	}
+
+	var fields []netstatField
+	for _, file := range []string{"snmp", "netstat"} {
+		values, err := readNetstat(procPath("net", file))
+		if err != nil {
+			slog.Error("failed to get netstat counters", "file", file, "error", err)
+			continue
+		}
+		fields = append(fields, values...)
+	}
+	// snmp6 is missing when IPv6 is disabled
+	values, err := readSnmp6(procPath("net", "snmp6"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Error("failed to get netstat counters", "file", "snmp6", "error", err)
+	}
+	fields = append(fields, values...)
+
+	for _, field := range fields {
+		name := field.protocol + "_" + field.field
+		if !c.selected(name) {
+			continue
+		}
+		if netstatGauges[name] {
+			ch <- prometheus.MustNewConstMetric(c.gauge, prometheus.GaugeValue, field.value, field.protocol, field.field)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.counter, prometheus.CounterValue, field.value, field.protocol, field.field)
+	}
+}
+
+func (c *netstatCollector) selected(name string) bool {
+	for _, re := range c.patterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// readNetstat parses /proc/net/snmp or /proc/net/netstat, where each protocol
+// has a line of field names followed by a line of values, e.g.
+//
+//	Tcp: RtoAlgorithm RtoMin RtoMax ...
+//	Tcp: 1 200 120000 ...
+func readNetstat(path string) ([]netstatField, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var fields []netstatField
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		names := strings.Fields(scanner.Text())
+		if len(names) == 0 {
+			continue
+		}
+		if !scanner.Scan() {
+			return nil, fmt.Errorf("missing values for %s in %s", names[0], path)
+		}
+		values := strings.Fields(scanner.Text())
+		if len(names) != len(values) || names[0] != values[0] {
+			return nil, fmt.Errorf("malformed netstat lines in %s", path)
+		}
+		protocol := strings.TrimSuffix(names[0], ":")
+		for i := 1; i < len(names); i++ {
+			value, err := strconv.ParseFloat(values[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("malformed value for %s %s in %s: %w", protocol, names[i], path, err)
+			}
+			fields = append(fields, netstatField{protocol: protocol, field: names[i], value: value})
+		}
+	}
+	return fields, scanner.Err()
+}
+
+// readSnmp6 parses /proc/net/snmp6, which holds one "Udp6InDatagrams 42"
+// pair per line.
+func readSnmp6(path string) ([]netstatField, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fields []netstatField
+	for _, line := range strings.Split(string(data), "\n") {
+		parts := strings.Fields(line)
+		if len(parts) != 2 {
+			continue
+		}
+		m := snmp6Pattern.FindStringSubmatch(parts[0])
+		if m == nil {
+			continue
+		}
+		value, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed value for %s in %s: %w", parts[0], path, err)
+		}
+		fields = append(fields, netstatField{protocol: m[1], field: m[2], value: value})
+	}
+	return fields, nil
+}
+
+// Describe implements prometheus.Collector.
+func (c *netstatCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.counter
+	ch <- c.gauge
+}
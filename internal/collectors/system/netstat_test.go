@@ -0,0 +1,90 @@
+package system
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReadNetstat(t *testing.T) {
+	tests := []struct {
+		file    string
+		want    []netstatField
+		wantErr bool
+	}{
+		{
+			file: "snmp",
+			want: []netstatField{
+				{protocol: "Ip", field: "Forwarding", value: 1},
+				{protocol: "Ip", field: "DefaultTTL", value: 64},
+				{protocol: "Ip", field: "InReceives", value: 12345},
+				{protocol: "Tcp", field: "RtoAlgorithm", value: 1},
+				{protocol: "Tcp", field: "MaxConn", value: -1},
+				{protocol: "Tcp", field: "ActiveOpens", value: 42},
+				{protocol: "Tcp", field: "CurrEstab", value: 7},
+				{protocol: "Udp", field: "InDatagrams", value: 1000},
+				{protocol: "Udp", field: "NoPorts", value: 3},
+				{protocol: "Udp", field: "InErrors", value: 0},
+			},
+		},
+		{
+			file: "netstat",
+			want: []netstatField{
+				{protocol: "TcpExt", field: "SyncookiesSent", value: 0},
+				{protocol: "TcpExt", field: "ListenOverflows", value: 12},
+				{protocol: "TcpExt", field: "ListenDrops", value: 12},
+				{protocol: "IpExt", field: "InNoRoutes", value: 0},
+				{protocol: "IpExt", field: "InOctets", value: 987654321},
+			},
+		},
+		{file: "mismatched-protocol", wantErr: true},
+		{file: "mismatched-count", wantErr: true},
+		{file: "missing-values", wantErr: true},
+		{file: "malformed-value", wantErr: true},
+		{file: "missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := readNetstat(filepath.Join("testdata", "netstat", tt.file))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readNetstat() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("readNetstat() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSnmp6(t *testing.T) {
+	tests := []struct {
+		file    string
+		want    []netstatField
+		wantErr bool
+	}{
+		{
+			file: "snmp6",
+			want: []netstatField{
+				{protocol: "Ip6", field: "InReceives", value: 1234},
+				{protocol: "Ip6", field: "OutRequests", value: 567},
+				{protocol: "Icmp6", field: "InMsgs", value: 8},
+				{protocol: "Icmp6", field: "InType135", value: 3},
+				{protocol: "Udp6", field: "InDatagrams", value: 42},
+				{protocol: "UdpLite6", field: "InDatagrams", value: 0},
+			},
+		},
+		{file: "snmp6-malformed-value", wantErr: true},
+		{file: "missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := readSnmp6(filepath.Join("testdata", "netstat", tt.file))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readSnmp6() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("readSnmp6() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
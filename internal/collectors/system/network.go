@@ -0,0 +1,120 @@
+package system
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shirou/gopsutil/v4/net"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*networkCollector)(nil)
+
+func NewNetworkCollector(config *config.Usage) (prometheus.Collector, error) {
+	return &networkCollector{
+		config:          config,
+		receiveBytes:    newNetworkDesc("receive_bytes_total", "Total number of bytes received"),
+		transmitBytes:   newNetworkDesc("transmit_bytes_total", "Total number of bytes transmitted"),
+		receivePackets:  newNetworkDesc("receive_packets_total", "Total number of packets received"),
+		transmitPackets: newNetworkDesc("transmit_packets_total", "Total number of packets transmitted"),
+		receiveErrs:     newNetworkDesc("receive_errs_total", "Total number of errors while receiving"),
+		transmitErrs:    newNetworkDesc("transmit_errs_total", "Total number of errors while transmitting"),
+		receiveDrop:     newNetworkDesc("receive_drop_total", "Total number of incoming packets which were dropped"),
+		transmitDrop:    newNetworkDesc("transmit_drop_total", "Total number of outgoing packets which were dropped"),
+		receiveFifo:     newNetworkDesc("receive_fifo_total", "Total number of FIFO buffer errors while receiving"),
+		transmitFifo:    newNetworkDesc("transmit_fifo_total", "Total number of FIFO buffer errors while transmitting"),
+		info: prometheus.NewDesc(
+			"system_network_info",
+			"Network interface information, value is always 1",
+			[]string{"device", "index", "mtu", "hardware_addr", "flags", "addrs"},
+			nil,
+		),
+	}, nil
+}
+
+func newNetworkDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName("system", "network", name), help, []string{"device"}, nil)
+}
+
+type networkCollector struct {
+	config *config.Usage
+
+	receiveBytes    *prometheus.Desc
+	transmitBytes   *prometheus.Desc
+	receivePackets  *prometheus.Desc
+	transmitPackets *prometheus.Desc
+	receiveErrs     *prometheus.Desc
+	transmitErrs    *prometheus.Desc
+	receiveDrop     *prometheus.Desc
+	transmitDrop    *prometheus.Desc
+	receiveFifo     *prometheus.Desc
+	transmitFifo    *prometheus.Desc
+	info            *prometheus.Desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *networkCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting network metrics")
+	if !c.config.Enabled {
+		slog.Warn("network metrics are not enabled")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.GetTimeout())
+	defer cancel()
+
+	ioCounters, err := net.IOCountersWithContext(ctx, true)
+	if err != nil {
+		slog.Error("failed to get network I/O counters", "error", err)
+	}
+	for _, io := range ioCounters {
+		ch <- prometheus.MustNewConstMetric(c.receiveBytes, prometheus.CounterValue, float64(io.BytesRecv), io.Name)
+		ch <- prometheus.MustNewConstMetric(c.transmitBytes, prometheus.CounterValue, float64(io.BytesSent), io.Name)
+		ch <- prometheus.MustNewConstMetric(c.receivePackets, prometheus.CounterValue, float64(io.PacketsRecv), io.Name)
+		ch <- prometheus.MustNewConstMetric(c.transmitPackets, prometheus.CounterValue, float64(io.PacketsSent), io.Name)
+		ch <- prometheus.MustNewConstMetric(c.receiveErrs, prometheus.CounterValue, float64(io.Errin), io.Name)
+		ch <- prometheus.MustNewConstMetric(c.transmitErrs, prometheus.CounterValue, float64(io.Errout), io.Name)
+		ch <- prometheus.MustNewConstMetric(c.receiveDrop, prometheus.CounterValue, float64(io.Dropin), io.Name)
+		ch <- prometheus.MustNewConstMetric(c.transmitDrop, prometheus.CounterValue, float64(io.Dropout), io.Name)
+		ch <- prometheus.MustNewConstMetric(c.receiveFifo, prometheus.CounterValue, float64(io.Fifoin), io.Name)
+		ch <- prometheus.MustNewConstMetric(c.transmitFifo, prometheus.CounterValue, float64(io.Fifoout), io.Name)
+	}
+
+	interfaces, err := net.InterfacesWithContext(ctx)
+	if err != nil {
+		slog.Error("failed to get network interfaces", "error", err)
+		return
+	}
+	for _, iface := range interfaces {
+		addrs := make([]string, 0, len(iface.Addrs))
+		for _, addr := range iface.Addrs {
+			addrs = append(addrs, addr.Addr)
+		}
+		ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1,
+			iface.Name,
+			strconv.Itoa(iface.Index),
+			strconv.Itoa(iface.MTU),
+			iface.HardwareAddr,
+			strings.Join(iface.Flags, ","),
+			strings.Join(addrs, ","),
+		)
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *networkCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.receiveBytes
+	ch <- c.transmitBytes
+	ch <- c.receivePackets
+	ch <- c.transmitPackets
+	ch <- c.receiveErrs
+	ch <- c.transmitErrs
+	ch <- c.receiveDrop
+	ch <- c.transmitDrop
+	ch <- c.receiveFifo
+	ch <- c.transmitFifo
+	ch <- c.info
+}
@@ -0,0 +1,159 @@
+package system
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*pressureCollector)(nil)
+
+// pressureResources are the resources the kernel tracks stall time for. irq
+// is only present on newer kernels built with IRQ time accounting.
+var pressureResources = []string{"cpu", "memory", "io", "irq"}
+
+func NewPressureCollector(config *config.PressureUsage) (prometheus.Collector, error) {
+	collector := &pressureCollector{
+		config:        config,
+		supported:     prometheus.NewDesc("system_pressure_supported", "Whether the kernel exposes pressure stall information", nil, nil),
+		stalled:       prometheus.NewDesc("system_pressure_stalled_seconds_total", "Total time tasks were stalled on the resource", []string{"resource", "kind"}, nil),
+		average:       prometheus.NewDesc("system_pressure_stalled_ratio", "Share of time tasks were stalled on the resource, averaged over the window", []string{"resource", "kind", "window"}, nil),
+		cgroupStalled: prometheus.NewDesc("system_cgroup_pressure_stalled_seconds_total", "Total time tasks of the cgroup were stalled on the resource", []string{"cgroup", "resource", "kind"}, nil),
+		cgroupAverage: prometheus.NewDesc("system_cgroup_pressure_stalled_ratio", "Share of time tasks of the cgroup were stalled on the resource, averaged over the window", []string{"cgroup", "resource", "kind", "window"}, nil),
+	}
+
+	// PSI is either missing (before Linux 4.20) or disabled with psi=0, in
+	// which case reading the files fails with EOPNOTSUPP. Check once so an
+	// unsupported host does not log an error on every scrape.
+	if _, err := readPressure(procPath("pressure", "cpu")); err != nil {
+		slog.Info("pressure stall information is not supported, disabling collector", "error", err)
+		return collector, nil
+	}
+	collector.isSupported = true
+	collector.cgroupRoot = cgroupV2Root()
+	if len(config.Cgroups) > 0 && collector.cgroupRoot == "" {
+		slog.Info("cgroup v2 is not mounted, cgroup pressure is not collected")
+	}
+	return collector, nil
+}
+
+type pressureCollector struct {
+	config *config.PressureUsage
+
+	isSupported bool
+	cgroupRoot  string
+
+	supported     *prometheus.Desc
+	stalled       *prometheus.Desc
+	average       *prometheus.Desc
+	cgroupStalled *prometheus.Desc
+	cgroupAverage *prometheus.Desc
+}
+
+// pressureLine is one line of a pressure file, e.g.
+// "some avg10=0.00 avg60=0.00 avg300=0.00 total=0".
+type pressureLine struct {
+	kind   string
+	avg10  float64
+	avg60  float64
+	avg300 float64
+	// total is the stall time in microseconds.
+	total float64
+}
+
+// Collect implements prometheus.Collector.
+func (c *pressureCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting pressure metrics")
+	if !c.config.Enabled {
+		slog.Warn("pressure metrics are not enabled")
+		return
+	}
+	if !c.isSupported {
+		ch <- prometheus.MustNewConstMetric(c.supported, prometheus.GaugeValue, 0)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.supported, prometheus.GaugeValue, 1)
+
+	for _, resource := range pressureResources {
+		lines, err := readPressure(procPath("pressure", resource))
+		if err != nil {
+			continue
+		}
+		for _, line := range lines {
+			ch <- prometheus.MustNewConstMetric(c.stalled, prometheus.CounterValue, line.total/1e6, resource, line.kind)
+			ch <- prometheus.MustNewConstMetric(c.average, prometheus.GaugeValue, line.avg10/100, resource, line.kind, "10s")
+			ch <- prometheus.MustNewConstMetric(c.average, prometheus.GaugeValue, line.avg60/100, resource, line.kind, "60s")
+			ch <- prometheus.MustNewConstMetric(c.average, prometheus.GaugeValue, line.avg300/100, resource, line.kind, "300s")
+		}
+	}
+
+	if c.cgroupRoot == "" {
+		return
+	}
+	for _, cgroup := range c.config.Cgroups {
+		for _, resource := range pressureResources {
+			lines, err := readPressure(filepath.Join(c.cgroupRoot, cgroup, resource+".pressure"))
+			if err != nil {
+				slog.Debug("failed to get cgroup pressure", "cgroup", cgroup, "resource", resource, "error", err)
+				continue
+			}
+			for _, line := range lines {
+				ch <- prometheus.MustNewConstMetric(c.cgroupStalled, prometheus.CounterValue, line.total/1e6, cgroup, resource, line.kind)
+				ch <- prometheus.MustNewConstMetric(c.cgroupAverage, prometheus.GaugeValue, line.avg10/100, cgroup, resource, line.kind, "10s")
+				ch <- prometheus.MustNewConstMetric(c.cgroupAverage, prometheus.GaugeValue, line.avg60/100, cgroup, resource, line.kind, "60s")
+				ch <- prometheus.MustNewConstMetric(c.cgroupAverage, prometheus.GaugeValue, line.avg300/100, cgroup, resource, line.kind, "300s")
+			}
+		}
+	}
+}
+
+// readPressure parses a /proc/pressure/<resource> or <cgroup>/<resource>.pressure file.
+func readPressure(path string) ([]pressureLine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lines []pressureLine
+	for _, text := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		fields := strings.Fields(text)
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("malformed pressure line %q in %s", text, path)
+		}
+		line := pressureLine{kind: fields[0]}
+		for _, field := range fields[1:] {
+			key, value, _ := strings.Cut(field, "=")
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("malformed pressure field %q in %s: %w", field, path, err)
+			}
+			switch key {
+			case "avg10":
+				line.avg10 = v
+			case "avg60":
+				line.avg60 = v
+			case "avg300":
+				line.avg300 = v
+			case "total":
+				line.total = v
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// Describe implements prometheus.Collector.
+func (c *pressureCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.supported
+	ch <- c.stalled
+	ch <- c.average
+	ch <- c.cgroupStalled
+	ch <- c.cgroupAverage
+}
@@ -0,0 +1,45 @@
+package system
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReadPressure(t *testing.T) {
+	tests := []struct {
+		file    string
+		want    []pressureLine
+		wantErr bool
+	}{
+		{
+			file: "memory",
+			want: []pressureLine{
+				{kind: "some", avg10: 2, avg60: 1.5, avg300: 0.75, total: 3000000},
+				{kind: "full", avg10: 0.5, avg60: 0.25, avg300: 0.1, total: 1000000},
+			},
+		},
+		{
+			// Kernels before 5.13 have no full line for cpu
+			file: "cpu-without-full",
+			want: []pressureLine{
+				{kind: "some", avg10: 1.23, avg60: 0.45, avg300: 0.06, total: 123456789},
+			},
+		},
+		{file: "truncated-line", wantErr: true},
+		{file: "malformed-value", wantErr: true},
+		{file: "empty", wantErr: true},
+		{file: "missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := readPressure(filepath.Join("testdata", "pressure", tt.file))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readPressure() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("readPressure() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shirou/gopsutil/v4/process"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*processCollector)(nil)
+
+func NewProcessCollector(config *config.ProcessUsage) (prometheus.Collector, error) {
+	groups := make([]*processGroupMatcher, 0, len(config.Groups))
+	names := make(map[string]bool, len(config.Groups))
+	for _, group := range config.Groups {
+		if names[group.Name] {
+			return nil, fmt.Errorf("duplicate process group %q", group.Name)
+		}
+		names[group.Name] = true
+		matcher, err := newProcessGroupMatcher(group)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, matcher)
+	}
+	groupLabels := []string{"group"}
+	return &processCollector{
+		config:      config,
+		groups:      groups,
+		totals:      make(map[string]*processCounters),
+		lastSeen:    make(map[processKey]processCounters),
+		cpuSeconds:  prometheus.NewDesc("system_process_group_cpu_seconds_total", "Total CPU time consumed by processes in the group", []string{"group", "mode"}, nil),
+		memoryBytes: prometheus.NewDesc("system_process_group_memory_bytes", "Memory used by processes in the group", []string{"group", "type"}, nil),
+		numProcs:    prometheus.NewDesc("system_process_group_num_procs", "Number of processes in the group", groupLabels, nil),
+		numThreads:  prometheus.NewDesc("system_process_group_num_threads", "Number of threads of processes in the group", groupLabels, nil),
+		openFDs:     prometheus.NewDesc("system_process_group_open_fds", "Number of open file descriptors of processes in the group", groupLabels, nil),
+		readBytes:   prometheus.NewDesc("system_process_group_read_bytes_total", "Total bytes read from storage by processes in the group", groupLabels, nil),
+		writeBytes:  prometheus.NewDesc("system_process_group_write_bytes_total", "Total bytes written to storage by processes in the group", groupLabels, nil),
+		oldestStart: prometheus.NewDesc("system_process_group_oldest_start_time_seconds", "Start time of the oldest process in the group since unix epoch", groupLabels, nil),
+	}, nil
+}
+
+type processCollector struct {
+	config *config.ProcessUsage
+	groups []*processGroupMatcher
+
+	// Counters summed over live processes would drop whenever a process
+	// exits, so the last value of every process is kept and only positive
+	// deltas are added to the per group totals.
+	mu       sync.Mutex
+	totals   map[string]*processCounters
+	lastSeen map[processKey]processCounters
+
+	cpuSeconds  *prometheus.Desc
+	memoryBytes *prometheus.Desc
+	numProcs    *prometheus.Desc
+	numThreads  *prometheus.Desc
+	openFDs     *prometheus.Desc
+	readBytes   *prometheus.Desc
+	writeBytes  *prometheus.Desc
+	oldestStart *prometheus.Desc
+}
+
+// processKey identifies a process across scrapes, guarding against PID reuse.
+type processKey struct {
+	pid        int32
+	createTime int64
+}
+
+type processCounters struct {
+	userSeconds   float64
+	systemSeconds float64
+	readBytes     float64
+	writeBytes    float64
+}
+
+// add accumulates the growth from last to current into c.
+func (c *processCounters) add(last, current processCounters) {
+	c.userSeconds += max(current.userSeconds-last.userSeconds, 0)
+	c.systemSeconds += max(current.systemSeconds-last.systemSeconds, 0)
+	c.readBytes += max(current.readBytes-last.readBytes, 0)
+	c.writeBytes += max(current.writeBytes-last.writeBytes, 0)
+}
+
+type processGroupStats struct {
+	procs      int
+	threads    int64
+	fds        int64
+	rss        uint64
+	vms        uint64
+	oldestTime int64
+}
+
+// Collect implements prometheus.Collector.
+func (c *processCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting process metrics")
+	if !c.config.Enabled {
+		slog.Warn("process metrics are not enabled")
+		return
+	}
+	if len(c.groups) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.GetTimeout())
+	defer cancel()
+
+	processes, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		slog.Error("failed to get processes", "error", err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := make(map[string]*processGroupStats, len(c.groups))
+	for _, group := range c.groups {
+		stats[group.name] = &processGroupStats{}
+		if _, ok := c.totals[group.name]; !ok {
+			c.totals[group.name] = &processCounters{}
+		}
+	}
+
+	seen := make(map[processKey]processCounters, len(c.lastSeen))
+	for _, proc := range processes {
+		if ctx.Err() != nil {
+			slog.Warn("process collection timed out", "error", ctx.Err())
+			// Keep the processes we did not get to, otherwise their whole
+			// history would be counted again on the next scrape.
+			for key, last := range c.lastSeen {
+				if _, ok := seen[key]; !ok {
+					seen[key] = last
+				}
+			}
+			break
+		}
+		group := c.match(ctx, proc)
+		if group == nil {
+			continue
+		}
+		createTime, err := proc.CreateTimeWithContext(ctx)
+		if err != nil {
+			continue
+		}
+		stat := stats[group.name]
+		stat.procs++
+		if stat.oldestTime == 0 || createTime < stat.oldestTime {
+			stat.oldestTime = createTime
+		}
+		if threads, err := proc.NumThreadsWithContext(ctx); err == nil {
+			stat.threads += int64(threads)
+		}
+		if fds, err := proc.NumFDsWithContext(ctx); err == nil {
+			stat.fds += int64(fds)
+		}
+		if memInfo, err := proc.MemoryInfoWithContext(ctx); err == nil {
+			stat.rss += memInfo.RSS
+			stat.vms += memInfo.VMS
+		}
+
+		var current processCounters
+		if times, err := proc.TimesWithContext(ctx); err == nil {
+			current.userSeconds = times.User
+			current.systemSeconds = times.System
+		}
+		if io, err := proc.IOCountersWithContext(ctx); err == nil {
+			current.readBytes = float64(io.ReadBytes)
+			current.writeBytes = float64(io.WriteBytes)
+		}
+		key := processKey{pid: proc.Pid, createTime: createTime}
+		c.totals[group.name].add(c.lastSeen[key], current)
+		seen[key] = current
+	}
+	c.lastSeen = seen
+
+	for _, group := range c.groups {
+		stat, totals := stats[group.name], c.totals[group.name]
+		ch <- prometheus.MustNewConstMetric(c.cpuSeconds, prometheus.CounterValue, totals.userSeconds, group.name, "user")
+		ch <- prometheus.MustNewConstMetric(c.cpuSeconds, prometheus.CounterValue, totals.systemSeconds, group.name, "system")
+		ch <- prometheus.MustNewConstMetric(c.readBytes, prometheus.CounterValue, totals.readBytes, group.name)
+		ch <- prometheus.MustNewConstMetric(c.writeBytes, prometheus.CounterValue, totals.writeBytes, group.name)
+		ch <- prometheus.MustNewConstMetric(c.memoryBytes, prometheus.GaugeValue, float64(stat.rss), group.name, "rss")
+		ch <- prometheus.MustNewConstMetric(c.memoryBytes, prometheus.GaugeValue, float64(stat.vms), group.name, "vms")
+		ch <- prometheus.MustNewConstMetric(c.numProcs, prometheus.GaugeValue, float64(stat.procs), group.name)
+		ch <- prometheus.MustNewConstMetric(c.numThreads, prometheus.GaugeValue, float64(stat.threads), group.name)
+		ch <- prometheus.MustNewConstMetric(c.openFDs, prometheus.GaugeValue, float64(stat.fds), group.name)
+		if stat.procs > 0 {
+			// CreateTime is reported in milliseconds
+			ch <- prometheus.MustNewConstMetric(c.oldestStart, prometheus.GaugeValue, float64(stat.oldestTime)/1000, group.name)
+		}
+	}
+}
+
+// match returns the first group the process belongs to, or nil.
+func (c *processCollector) match(ctx context.Context, proc *process.Process) *processGroupMatcher {
+	for _, group := range c.groups {
+		if group.matches(ctx, proc) {
+			return group
+		}
+	}
+	return nil
+}
+
+// Describe implements prometheus.Collector.
+func (c *processCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.cpuSeconds
+	ch <- c.memoryBytes
+	ch <- c.numProcs
+	ch <- c.numThreads
+	ch <- c.openFDs
+	ch <- c.readBytes
+	ch <- c.writeBytes
+	ch <- c.oldestStart
+}
+
+type processGroupMatcher struct {
+	name    string
+	exe     []string
+	cmdline *regexp.Regexp
+	user    []string
+	cgroup  *regexp.Regexp
+}
+
+func newProcessGroupMatcher(group config.ProcessGroup) (*processGroupMatcher, error) {
+	if group.Name == "" {
+		return nil, fmt.Errorf("process group name is required")
+	}
+	matcher := &processGroupMatcher{
+		name: group.Name,
+		exe:  group.Exe,
+		user: group.User,
+	}
+	var err error
+	if group.Cmdline != "" {
+		if matcher.cmdline, err = regexp.Compile(group.Cmdline); err != nil {
+			return nil, fmt.Errorf("invalid cmdline pattern for process group %q: %w", group.Name, err)
+		}
+	}
+	if group.Cgroup != "" {
+		if matcher.cgroup, err = regexp.Compile(group.Cgroup); err != nil {
+			return nil, fmt.Errorf("invalid cgroup pattern for process group %q: %w", group.Name, err)
+		}
+	}
+	return matcher, nil
+}
+
+// matches reports whether every configured matcher accepts the process. The
+// cheaper checks run first so most processes are rejected early.
+func (m *processGroupMatcher) matches(ctx context.Context, proc *process.Process) bool {
+	if len(m.exe) > 0 && !m.matchesExe(ctx, proc) {
+		return false
+	}
+	if len(m.user) > 0 {
+		username, err := proc.UsernameWithContext(ctx)
+		if err != nil || !slices.Contains(m.user, username) {
+			return false
+		}
+	}
+	if m.cmdline != nil {
+		cmdline, err := proc.CmdlineWithContext(ctx)
+		if err != nil || !m.cmdline.MatchString(cmdline) {
+			return false
+		}
+	}
+	if m.cgroup != nil && !slices.ContainsFunc(processCgroups(proc.Pid), m.cgroup.MatchString) {
+		return false
+	}
+	return true
+}
+
+func (m *processGroupMatcher) matchesExe(ctx context.Context, proc *process.Process) bool {
+	if name, err := proc.NameWithContext(ctx); err == nil && slices.Contains(m.exe, name) {
+		return true
+	}
+	exe, err := proc.ExeWithContext(ctx)
+	if err != nil || exe == "" {
+		return false
+	}
+	return slices.Contains(m.exe, exe) || slices.Contains(m.exe, filepath.Base(exe))
+}
+
+// processCgroups returns the cgroup paths of the process from /proc/<pid>/cgroup.
+func processCgroups(pid int32) []string {
+	file, err := os.Open(procPath(strconv.Itoa(int(pid)), "cgroup"))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var cgroups []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Each line is hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) == 3 {
+			cgroups = append(cgroups, parts[2])
+		}
+	}
+	return cgroups
+}
@@ -0,0 +1,95 @@
+package system
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// procPath joins elem onto the proc filesystem root. Like gopsutil, the root
+// can be moved with HOST_PROC when running inside a container.
+func procPath(elem ...string) string {
+	root := os.Getenv("HOST_PROC")
+	if root == "" {
+		root = "/proc"
+	}
+	return filepath.Join(append([]string{root}, elem...)...)
+}
+
+// sysPath joins elem onto the sys filesystem root, honouring HOST_SYS.
+func sysPath(elem ...string) string {
+	root := os.Getenv("HOST_SYS")
+	if root == "" {
+		root = "/sys"
+	}
+	return filepath.Join(append([]string{root}, elem...)...)
+}
+
+// readUintFile reads a file holding a single unsigned integer, such as the
+// sysctl values under /proc/sys.
+func readUintFile(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// listPIDs returns the numeric entries of the proc filesystem root.
+func listPIDs() ([]int, error) {
+	entries, err := os.ReadDir(procPath())
+	if err != nil {
+		return nil, err
+	}
+	pids := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		if pid, err := strconv.Atoi(entry.Name()); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// readProcStat returns the command name and scheduler state from a
+// /proc/<pid>/stat or /proc/<pid>/task/<tid>/stat file. The command name may
+// itself contain spaces and parentheses, so it is delimited by the first "("
+// and the last ")".
+func readProcStat(path string) (comm string, state byte, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, err
+	}
+	start, end := bytes.IndexByte(data, '('), bytes.LastIndexByte(data, ')')
+	if start < 0 || end < start || end+2 >= len(data) {
+		return "", 0, fmt.Errorf("malformed stat file %s", path)
+	}
+	return string(data[start+1 : end]), data[end+2], nil
+}
+
+// listDirNames returns the names of the entries in dir.
+func listDirNames(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names, nil
+}
+
+// cgroupV2Root returns the mountpoint of the cgroup v2 unified hierarchy, or
+// an empty string when the host does not have one. Hybrid setups mount it
+// below the v1 controllers at /sys/fs/cgroup/unified.
+func cgroupV2Root() string {
+	for _, root := range []string{sysPath("fs", "cgroup"), sysPath("fs", "cgroup", "unified")} {
+		if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+			return root
+		}
+	}
+	return ""
+}
@@ -0,0 +1,273 @@
+package system
+
+import (
+	"bufio"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*raidCollector)(nil)
+
+var (
+	// mdDiskCountPattern matches the "[2/1]" of an array status line, the
+	// number of disks the array needs and has.
+	mdDiskCountPattern = regexp.MustCompile(`\[(\d+)/(\d+)\]`)
+	// mdProgressPattern matches an action in progress, e.g.
+	// "recovery =  8.5% (8768/102336) finish=0.1min speed=8768K/sec".
+	mdProgressPattern = regexp.MustCompile(`(resync|recovery|reshape|check|repair)\s*=\s*([\d.]+)%`)
+	mdSpeedPattern    = regexp.MustCompile(`speed=(\d+)K/sec`)
+	// mdMemberPattern matches a member disk, e.g. sdc1[2](F).
+	mdMemberPattern = regexp.MustCompile(`^\S+\[\d+\]((?:\([A-Z]\))*)$`)
+)
+
+// ignoredBlockDevices are the virtual block devices without a device or
+// queue worth reporting.
+var ignoredBlockDevices = []string{"loop", "ram", "zram"}
+
+func NewRAIDCollector(config *config.Usage) (prometheus.Collector, error) {
+	mdLabels := []string{"device"}
+	blockLabels := []string{"device"}
+	return &raidCollector{
+		config:          config,
+		mdState:         prometheus.NewDesc("system_md_state", "Current state of the md array, from md/array_state or /proc/mdstat", []string{"device", "level", "state"}, nil),
+		mdDisks:         prometheus.NewDesc("system_md_disks", "Member disks of the md array by state: active, failed or spare", []string{"device", "state"}, nil),
+		mdDisksNeeded:   prometheus.NewDesc("system_md_disks_required", "Number of disks the md array needs to be complete", mdLabels, nil),
+		mdDegraded:      prometheus.NewDesc("system_md_degraded", "Number of disks missing from the md array", mdLabels, nil),
+		mdSyncProgress:  prometheus.NewDesc("system_md_sync_progress_ratio", "Progress of the resync, recovery, reshape or check of the md array", []string{"device", "action"}, nil),
+		mdSyncSpeed:     prometheus.NewDesc("system_md_sync_speed_bytes_per_second", "Speed of the resync, recovery, reshape or check of the md array", []string{"device", "action"}, nil),
+		deviceState:     prometheus.NewDesc("system_block_device_state", "Current state of the block device, e.g. running or offline", []string{"device", "state"}, nil),
+		queueRequests:   prometheus.NewDesc("system_block_queue_nr_requests", "Number of requests the block device queue can hold", blockLabels, nil),
+		queueReadAhead:  prometheus.NewDesc("system_block_queue_read_ahead_bytes", "Read ahead of the block device", blockLabels, nil),
+		queueRotational: prometheus.NewDesc("system_block_queue_rotational", "Whether the block device is rotational", blockLabels, nil),
+		queueScheduler:  prometheus.NewDesc("system_block_queue_scheduler", "I/O scheduler selected for the block device", []string{"device", "scheduler"}, nil),
+	}, nil
+}
+
+type raidCollector struct {
+	config *config.Usage
+
+	mdState         *prometheus.Desc
+	mdDisks         *prometheus.Desc
+	mdDisksNeeded   *prometheus.Desc
+	mdDegraded      *prometheus.Desc
+	mdSyncProgress  *prometheus.Desc
+	mdSyncSpeed     *prometheus.Desc
+	deviceState     *prometheus.Desc
+	queueRequests   *prometheus.Desc
+	queueReadAhead  *prometheus.Desc
+	queueRotational *prometheus.Desc
+	queueScheduler  *prometheus.Desc
+}
+
+// mdArray is an md array as described by /proc/mdstat.
+type mdArray struct {
+	name  string
+	state string
+	level string
+	// required is the number of disks the array needs, active the number
+	// it has. Both are -1 for inactive arrays, which have no status line.
+	required int
+	active   int
+	failed   int
+	spare    int
+	// action is the resync, recovery, reshape or check in progress, with
+	// progress between 0 and 1 and speed in bytes per second.
+	action   string
+	progress float64
+	speed    float64
+}
+
+// Collect implements prometheus.Collector.
+func (c *raidCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting RAID metrics")
+	if !c.config.Enabled {
+		slog.Warn("RAID metrics are not enabled")
+		return
+	}
+
+	// mdstat is missing when the md driver is not loaded
+	arrays, err := readMdstat(procPath("mdstat"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Error("failed to get md arrays", "error", err)
+	}
+	for _, array := range arrays {
+		c.collectArray(ch, array)
+	}
+	c.collectBlockDevices(ch)
+}
+
+func (c *raidCollector) collectArray(ch chan<- prometheus.Metric, array mdArray) {
+	mdDir := sysPath("block", array.name, "md")
+	state := readSysfsString(filepath.Join(mdDir, "array_state"))
+	if state == "" {
+		state = array.state
+	}
+	ch <- prometheus.MustNewConstMetric(c.mdState, prometheus.GaugeValue, 1, array.name, array.level, state)
+
+	if array.required >= 0 {
+		ch <- prometheus.MustNewConstMetric(c.mdDisksNeeded, prometheus.GaugeValue, float64(array.required), array.name)
+		ch <- prometheus.MustNewConstMetric(c.mdDisks, prometheus.GaugeValue, float64(array.active), array.name, "active")
+		degraded := float64(array.required - array.active)
+		if value, err := readSysfsFloat(filepath.Join(mdDir, "degraded")); err == nil {
+			degraded = value
+		}
+		ch <- prometheus.MustNewConstMetric(c.mdDegraded, prometheus.GaugeValue, degraded, array.name)
+	}
+	ch <- prometheus.MustNewConstMetric(c.mdDisks, prometheus.GaugeValue, float64(array.failed), array.name, "failed")
+	ch <- prometheus.MustNewConstMetric(c.mdDisks, prometheus.GaugeValue, float64(array.spare), array.name, "spare")
+
+	if array.action != "" {
+		ch <- prometheus.MustNewConstMetric(c.mdSyncProgress, prometheus.GaugeValue, array.progress, array.name, array.action)
+		ch <- prometheus.MustNewConstMetric(c.mdSyncSpeed, prometheus.GaugeValue, array.speed, array.name, array.action)
+	}
+}
+
+func (c *raidCollector) collectBlockDevices(ch chan<- prometheus.Metric) {
+	dir := sysPath("block")
+	devices, err := listDirNames(dir)
+	if err != nil {
+		slog.Error("failed to get block devices", "error", err)
+		return
+	}
+	for _, device := range devices {
+		if ignoredBlockDevice(device) {
+			continue
+		}
+		// Only SCSI devices report a state
+		if state := readSysfsString(filepath.Join(dir, device, "device", "state")); state != "" {
+			ch <- prometheus.MustNewConstMetric(c.deviceState, prometheus.GaugeValue, 1, device, state)
+		}
+
+		queueDir := filepath.Join(dir, device, "queue")
+		if value, err := readSysfsFloat(filepath.Join(queueDir, "nr_requests")); err == nil {
+			ch <- prometheus.MustNewConstMetric(c.queueRequests, prometheus.GaugeValue, value, device)
+		}
+		if value, err := readSysfsFloat(filepath.Join(queueDir, "read_ahead_kb")); err == nil {
+			ch <- prometheus.MustNewConstMetric(c.queueReadAhead, prometheus.GaugeValue, value*1024, device)
+		}
+		if value, err := readSysfsFloat(filepath.Join(queueDir, "rotational")); err == nil {
+			ch <- prometheus.MustNewConstMetric(c.queueRotational, prometheus.GaugeValue, value, device)
+		}
+		if scheduler := selectedScheduler(readSysfsString(filepath.Join(queueDir, "scheduler"))); scheduler != "" {
+			ch <- prometheus.MustNewConstMetric(c.queueScheduler, prometheus.GaugeValue, 1, device, scheduler)
+		}
+	}
+}
+
+func ignoredBlockDevice(device string) bool {
+	for _, prefix := range ignoredBlockDevices {
+		if strings.HasPrefix(device, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectedScheduler returns the bracketed entry of a queue/scheduler file
+// such as "none [mq-deadline] kyber", or the only entry of a device that
+// cannot switch schedulers.
+func selectedScheduler(schedulers string) string {
+	fields := strings.Fields(schedulers)
+	if len(fields) == 1 {
+		return strings.Trim(fields[0], "[]")
+	}
+	for _, field := range fields {
+		if strings.HasPrefix(field, "[") && strings.HasSuffix(field, "]") {
+			return strings.Trim(field, "[]")
+		}
+	}
+	return ""
+}
+
+// readMdstat parses /proc/mdstat, e.g.
+//
+//	md0 : active raid1 sdb1[1] sda1[0](F)
+//	      102336 blocks super 1.2 [2/1] [U_]
+//	      [=>...................]  recovery =  8.5% (8768/102336) finish=0.1min speed=8768K/sec
+func readMdstat(path string) ([]mdArray, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var arrays []mdArray
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		name, rest, ok := strings.Cut(line, " : ")
+		if ok && strings.HasPrefix(name, "md") {
+			arrays = append(arrays, parseMdHeader(name, rest))
+			continue
+		}
+		if len(arrays) == 0 || !strings.HasPrefix(line, " ") {
+			continue
+		}
+		array := &arrays[len(arrays)-1]
+		if m := mdDiskCountPattern.FindStringSubmatch(line); m != nil && array.required < 0 {
+			array.required, _ = strconv.Atoi(m[1])
+			array.active, _ = strconv.Atoi(m[2])
+		}
+		if m := mdProgressPattern.FindStringSubmatch(line); m != nil {
+			percent, _ := strconv.ParseFloat(m[2], 64)
+			array.action, array.progress = m[1], percent/100
+			if m := mdSpeedPattern.FindStringSubmatch(line); m != nil {
+				speed, _ := strconv.ParseFloat(m[1], 64)
+				array.speed = speed * 1024
+			}
+		}
+	}
+	return arrays, scanner.Err()
+}
+
+// parseMdHeader parses the part of an array line after "md0 : ", e.g.
+// "active (auto-read-only) raid1 sdb1[1] sda1[0](F)".
+func parseMdHeader(name, header string) mdArray {
+	array := mdArray{name: name, required: -1, active: -1}
+	fields := strings.Fields(header)
+	if len(fields) == 0 {
+		return array
+	}
+	array.state = fields[0]
+	for _, field := range fields[1:] {
+		m := mdMemberPattern.FindStringSubmatch(field)
+		switch {
+		case m != nil:
+			switch {
+			case strings.Contains(m[1], "(F)"):
+				array.failed++
+			case strings.Contains(m[1], "(S)"):
+				array.spare++
+			}
+		case strings.HasPrefix(field, "("):
+			// A qualifier of the state such as (auto-read-only)
+		case array.level == "":
+			array.level = field
+		}
+	}
+	return array
+}
+
+// Describe implements prometheus.Collector.
+func (c *raidCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.mdState
+	ch <- c.mdDisks
+	ch <- c.mdDisksNeeded
+	ch <- c.mdDegraded
+	ch <- c.mdSyncProgress
+	ch <- c.mdSyncSpeed
+	ch <- c.deviceState
+	ch <- c.queueRequests
+	ch <- c.queueReadAhead
+	ch <- c.queueRotational
+	ch <- c.queueScheduler
+}
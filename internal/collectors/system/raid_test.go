@@ -0,0 +1,75 @@
+package system
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadMdstat(t *testing.T) {
+	tests := []struct {
+		file string
+		want []mdArray
+	}{
+		{
+			file: "degraded",
+			want: []mdArray{
+				{name: "md0", state: "active", level: "raid1", required: 2, active: 1, failed: 1},
+			},
+		},
+		{
+			file: "recovery",
+			want: []mdArray{
+				{name: "md1", state: "active", level: "raid1", required: 2, active: 1, action: "recovery", progress: 0.085, speed: 8768 * 1024},
+				{name: "md2", state: "active", level: "raid5", required: 3, active: 3, action: "resync", progress: 0.473, speed: 20650 * 1024},
+			},
+		},
+		{
+			file: "inactive",
+			want: []mdArray{
+				{name: "md127", state: "inactive", required: -1, active: -1, spare: 2},
+			},
+		},
+		{
+			file: "auto-read-only",
+			want: []mdArray{
+				{name: "md3", state: "active", level: "raid1", required: 2, active: 2, spare: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := readMdstat(filepath.Join("testdata", "mdstat", tt.file))
+			if err != nil {
+				t.Fatalf("readMdstat() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("readMdstat() = %+v, want %+v", got, tt.want)
+			}
+			for i, want := range tt.want {
+				array := got[i]
+				if math.Abs(array.progress-want.progress) > 1e-9 {
+					t.Errorf("%s progress = %v, want %v", want.name, array.progress, want.progress)
+				}
+				array.progress = want.progress
+				if array != want {
+					t.Errorf("array %d = %+v, want %+v", i, array, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectedScheduler(t *testing.T) {
+	tests := map[string]string{
+		"mq-deadline kyber [bfq] none": "bfq",
+		"[none] mq-deadline":           "none",
+		"none":                         "none",
+		"":                             "",
+	}
+	for schedulers, want := range tests {
+		if got := selectedScheduler(schedulers); got != want {
+			t.Errorf("selectedScheduler(%q) = %q, want %q", schedulers, got, want)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package system
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*sensorCollector)(nil)
+
+// hwmonInputPattern matches the sensor input files of a hwmon chip, see
+// https://www.kernel.org/doc/Documentation/hwmon/sysfs-interface.
+var hwmonInputPattern = regexp.MustCompile(`^(temp|fan|in)(\d+)_input$`)
+
+// hwmonScale converts the sysfs units (millidegree Celsius, RPM, millivolt)
+// to the exported base units.
+var hwmonScale = map[string]float64{
+	"temp": 1000,
+	"fan":  1,
+	"in":   1000,
+}
+
+func NewSensorCollector(config *config.SensorUsage) (prometheus.Collector, error) {
+	root := config.SysfsRoot
+	if root == "" {
+		root = sysPath()
+	}
+	hwmonLabels := []string{"chip", "device", "sensor"}
+	thermalLabels := []string{"zone", "type"}
+	return &sensorCollector{
+		config:        config,
+		root:          root,
+		temp:          prometheus.NewDesc("system_hwmon_temp_celsius", "Hardware monitor temperature reading", hwmonLabels, nil),
+		tempMax:       prometheus.NewDesc("system_hwmon_temp_max_celsius", "Hardware monitor temperature high threshold", hwmonLabels, nil),
+		tempCrit:      prometheus.NewDesc("system_hwmon_temp_crit_celsius", "Hardware monitor temperature critical threshold", hwmonLabels, nil),
+		fan:           prometheus.NewDesc("system_hwmon_fan_rpm", "Hardware monitor fan speed", hwmonLabels, nil),
+		fanMax:        prometheus.NewDesc("system_hwmon_fan_max_rpm", "Hardware monitor fan maximum speed", hwmonLabels, nil),
+		voltage:       prometheus.NewDesc("system_hwmon_voltage_volts", "Hardware monitor voltage reading", hwmonLabels, nil),
+		voltageMax:    prometheus.NewDesc("system_hwmon_voltage_max_volts", "Hardware monitor voltage high threshold", hwmonLabels, nil),
+		voltageCrit:   prometheus.NewDesc("system_hwmon_voltage_crit_volts", "Hardware monitor voltage critical threshold", hwmonLabels, nil),
+		critAlarm:     prometheus.NewDesc("system_hwmon_crit_alarm", "Whether the hardware monitor sensor is at or above its critical threshold", hwmonLabels, nil),
+		zoneTemp:      prometheus.NewDesc("system_thermal_zone_temp_celsius", "Thermal zone temperature", thermalLabels, nil),
+		zoneCrit:      prometheus.NewDesc("system_thermal_zone_crit_celsius", "Thermal zone critical trip point", thermalLabels, nil),
+		zoneCritAlarm: prometheus.NewDesc("system_thermal_zone_crit_alarm", "Whether the thermal zone is at or above its critical trip point", thermalLabels, nil),
+	}, nil
+}
+
+type sensorCollector struct {
+	config *config.SensorUsage
+	root   string
+
+	temp          *prometheus.Desc
+	tempMax       *prometheus.Desc
+	tempCrit      *prometheus.Desc
+	fan           *prometheus.Desc
+	fanMax        *prometheus.Desc
+	voltage       *prometheus.Desc
+	voltageMax    *prometheus.Desc
+	voltageCrit   *prometheus.Desc
+	critAlarm     *prometheus.Desc
+	zoneTemp      *prometheus.Desc
+	zoneCrit      *prometheus.Desc
+	zoneCritAlarm *prometheus.Desc
+}
+
+// hwmonSensor is one input of a hwmon chip, with values in base units.
+type hwmonSensor struct {
+	chip   string
+	device string
+	// kind is the sysfs prefix: temp, fan or in.
+	kind  string
+	label string
+	input float64
+	// max and crit are NaN when the chip does not report the threshold.
+	max   float64
+	crit  float64
+	alarm bool
+}
+
+// thermalZone is a /sys/class/thermal/thermal_zone* entry.
+type thermalZone struct {
+	zone string
+	kind string
+	temp float64
+	// crit is NaN when the zone has no critical trip point.
+	crit float64
+}
+
+// Collect implements prometheus.Collector.
+func (c *sensorCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting sensor metrics")
+	if !c.config.Enabled {
+		slog.Warn("sensor metrics are not enabled")
+		return
+	}
+
+	sensors, err := readHwmonSensors(c.root)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Error("failed to get hwmon sensors", "error", err)
+	}
+	for _, sensor := range sensors {
+		labels := []string{sensor.chip, sensor.device, sensor.label}
+		input, maximum, critical := c.descs(sensor.kind)
+		ch <- prometheus.MustNewConstMetric(input, prometheus.GaugeValue, sensor.input, labels...)
+		if !math.IsNaN(sensor.max) {
+			ch <- prometheus.MustNewConstMetric(maximum, prometheus.GaugeValue, sensor.max, labels...)
+		}
+		if critical != nil && !math.IsNaN(sensor.crit) {
+			ch <- prometheus.MustNewConstMetric(critical, prometheus.GaugeValue, sensor.crit, labels...)
+		}
+		if !math.IsNaN(sensor.crit) || sensor.alarm {
+			ch <- prometheus.MustNewConstMetric(c.critAlarm, prometheus.GaugeValue, boolToFloat(sensor.alarm), labels...)
+		}
+	}
+
+	zones, err := readThermalZones(c.root)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Error("failed to get thermal zones", "error", err)
+	}
+	for _, zone := range zones {
+		labels := []string{zone.zone, zone.kind}
+		ch <- prometheus.MustNewConstMetric(c.zoneTemp, prometheus.GaugeValue, zone.temp, labels...)
+		if !math.IsNaN(zone.crit) {
+			ch <- prometheus.MustNewConstMetric(c.zoneCrit, prometheus.GaugeValue, zone.crit, labels...)
+			ch <- prometheus.MustNewConstMetric(c.zoneCritAlarm, prometheus.GaugeValue, boolToFloat(zone.temp >= zone.crit), labels...)
+		}
+	}
+}
+
+// descs returns the input, max and crit descriptors of a sensor kind. Fans
+// have no critical threshold, a fan is in trouble when it is too slow.
+func (c *sensorCollector) descs(kind string) (input, maximum, critical *prometheus.Desc) {
+	switch kind {
+	case "temp":
+		return c.temp, c.tempMax, c.tempCrit
+	case "fan":
+		return c.fan, c.fanMax, nil
+	default:
+		return c.voltage, c.voltageMax, c.voltageCrit
+	}
+}
+
+// readHwmonSensors reads every sensor input below <root>/class/hwmon. A
+// sensor whose chip, device and label repeat an earlier one, such as two
+// inputs a driver labels alike, has its attribute prefix appended to the
+// label, since a repeated series fails the scrape. The kind is not part of
+// the comparison because system_hwmon_crit_alarm has no kind label.
+func readHwmonSensors(root string) ([]hwmonSensor, error) {
+	hwmonDir := filepath.Join(root, "class", "hwmon")
+	chips, err := listDirNames(hwmonDir)
+	if err != nil {
+		return nil, err
+	}
+	var sensors []hwmonSensor
+	seen := make(map[[3]string]bool)
+	for _, chip := range chips {
+		dir := filepath.Join(hwmonDir, chip)
+		name := readSysfsString(filepath.Join(dir, "name"))
+		if name == "" {
+			// Older drivers keep their attributes on the device itself
+			dir = filepath.Join(dir, "device")
+			name = readSysfsString(filepath.Join(dir, "name"))
+		}
+		if name == "" {
+			name = chip
+		}
+		device := ""
+		if target, err := filepath.EvalSymlinks(filepath.Join(hwmonDir, chip, "device")); err == nil {
+			device = filepath.Base(target)
+		}
+
+		files, err := listDirNames(dir)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			m := hwmonInputPattern.FindStringSubmatch(file)
+			if m == nil {
+				continue
+			}
+			prefix := m[1] + m[2]
+			input, err := readSysfsFloat(filepath.Join(dir, file))
+			if err != nil {
+				continue
+			}
+			scale := hwmonScale[m[1]]
+			sensor := hwmonSensor{
+				chip:   name,
+				device: device,
+				kind:   m[1],
+				label:  readSysfsString(filepath.Join(dir, prefix+"_label")),
+				input:  input / scale,
+				max:    math.NaN(),
+				crit:   math.NaN(),
+			}
+			if sensor.label == "" {
+				sensor.label = prefix
+			}
+			key := [3]string{sensor.chip, sensor.device, sensor.label}
+			if seen[key] {
+				sensor.label += " (" + prefix + ")"
+				key[2] = sensor.label
+			}
+			if seen[key] {
+				// Still repeated, e.g. by two identical chips without a device
+				continue
+			}
+			seen[key] = true
+			if value, err := readSysfsFloat(filepath.Join(dir, prefix+"_max")); err == nil {
+				sensor.max = value / scale
+			}
+			if value, err := readSysfsFloat(filepath.Join(dir, prefix+"_crit")); err == nil && sensor.kind != "fan" {
+				sensor.crit = value / scale
+			}
+			alarm, err := readSysfsFloat(filepath.Join(dir, prefix+"_crit_alarm"))
+			sensor.alarm = (err == nil && alarm != 0) || (!math.IsNaN(sensor.crit) && sensor.crit > 0 && sensor.input >= sensor.crit)
+			sensors = append(sensors, sensor)
+		}
+	}
+	return sensors, nil
+}
+
+// readThermalZones reads every <root>/class/thermal/thermal_zone* entry.
+func readThermalZones(root string) ([]thermalZone, error) {
+	thermalDir := filepath.Join(root, "class", "thermal")
+	entries, err := listDirNames(thermalDir)
+	if err != nil {
+		return nil, err
+	}
+	var zones []thermalZone
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry, "thermal_zone") {
+			continue
+		}
+		dir := filepath.Join(thermalDir, entry)
+		temp, err := readSysfsFloat(filepath.Join(dir, "temp"))
+		if err != nil {
+			// Disabled zones fail to read
+			continue
+		}
+		zone := thermalZone{
+			zone: strings.TrimPrefix(entry, "thermal_zone"),
+			kind: readSysfsString(filepath.Join(dir, "type")),
+			temp: temp / 1000,
+			crit: math.NaN(),
+		}
+		for i := 0; ; i++ {
+			trip := "trip_point_" + strconv.Itoa(i)
+			tripType, err := os.ReadFile(filepath.Join(dir, trip+"_type"))
+			if err != nil {
+				break
+			}
+			if strings.TrimSpace(string(tripType)) != "critical" {
+				continue
+			}
+			if value, err := readSysfsFloat(filepath.Join(dir, trip+"_temp")); err == nil {
+				zone.crit = value / 1000
+			}
+		}
+		zones = append(zones, zone)
+	}
+	return zones, nil
+}
+
+// readSysfsString returns the trimmed content of a sysfs attribute, or an
+// empty string when it cannot be read.
+func readSysfsString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func readSysfsFloat(path string) (float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+}
+
+// Describe implements prometheus.Collector.
+func (c *sensorCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.temp
+	ch <- c.tempMax
+	ch <- c.tempCrit
+	ch <- c.fan
+	ch <- c.fanMax
+	ch <- c.voltage
+	ch <- c.voltageMax
+	ch <- c.voltageCrit
+	ch <- c.critAlarm
+	ch <- c.zoneTemp
+	ch <- c.zoneCrit
+	ch <- c.zoneCritAlarm
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,109 @@
+package system
+
+import (
+	"math"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+const sensorRoot = "testdata/sensors"
+
+// sameFloat reports whether a and b are equal, treating NaN as equal to NaN.
+func sameFloat(a, b float64) bool {
+	return a == b || (math.IsNaN(a) && math.IsNaN(b))
+}
+
+func TestReadHwmonSensors(t *testing.T) {
+	nan := math.NaN()
+	want := []hwmonSensor{
+		{chip: "coretemp", device: "coretemp.0", kind: "temp", label: "Package id 0", input: 45, max: 80, crit: 100},
+		{chip: "coretemp", device: "coretemp.0", kind: "temp", label: "Core 0", input: 101, max: nan, crit: 100, alarm: true},
+		{chip: "coretemp", device: "coretemp.0", kind: "temp", label: "Core 0 (temp3)", input: 50, max: nan, crit: nan},
+		// Fans have no critical threshold even when the chip reports one
+		{chip: "nct6775", device: "nct6775.656", kind: "fan", label: "fan1", input: 1200, max: 3000, crit: nan},
+		{chip: "nct6775", device: "nct6775.656", kind: "in", label: "Vcore", input: 1.104, max: 1.5, crit: 2, alarm: true},
+		// A temperature labelled like a voltage would repeat its crit_alarm
+		{chip: "nct6775", device: "nct6775.656", kind: "temp", label: "Vcore (temp1)", input: 40, max: nan, crit: 90},
+		// hwmon2 keeps its attributes on the device
+		{chip: "it87", device: "it87.552", kind: "temp", label: "temp1", input: 38, max: nan, crit: nan},
+	}
+
+	got, err := readHwmonSensors(sensorRoot)
+	if err != nil {
+		t.Fatalf("readHwmonSensors() error = %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("readHwmonSensors() = %+v, want %+v", got, want)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.chip != w.chip || g.device != w.device || g.kind != w.kind || g.label != w.label || g.alarm != w.alarm ||
+			!sameFloat(g.input, w.input) || !sameFloat(g.max, w.max) || !sameFloat(g.crit, w.crit) {
+			t.Errorf("sensor %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func TestReadThermalZones(t *testing.T) {
+	want := []thermalZone{
+		{zone: "0", kind: "x86_pkg_temp", temp: 55, crit: 105},
+		{zone: "1", kind: "acpitz", temp: 20, crit: math.NaN()},
+	}
+
+	got, err := readThermalZones(sensorRoot)
+	if err != nil {
+		t.Fatalf("readThermalZones() error = %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("readThermalZones() = %+v, want %+v", got, want)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.zone != w.zone || g.kind != w.kind || !sameFloat(g.temp, w.temp) || !sameFloat(g.crit, w.crit) {
+			t.Errorf("zone %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func TestSensorCollectorGather(t *testing.T) {
+	collector, err := NewSensorCollector(&config.SensorUsage{
+		Usage:     config.Usage{Enabled: true},
+		SysfsRoot: sensorRoot,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+
+	samples := make(map[string]int)
+	for _, family := range families {
+		samples[family.GetName()] = len(family.GetMetric())
+	}
+	want := map[string]int{
+		"system_hwmon_temp_celsius":        5,
+		"system_hwmon_temp_max_celsius":    1,
+		"system_hwmon_temp_crit_celsius":   3,
+		"system_hwmon_fan_rpm":             1,
+		"system_hwmon_fan_max_rpm":         1,
+		"system_hwmon_voltage_volts":       1,
+		"system_hwmon_voltage_max_volts":   1,
+		"system_hwmon_voltage_crit_volts":  1,
+		"system_hwmon_crit_alarm":          4,
+		"system_thermal_zone_temp_celsius": 2,
+		"system_thermal_zone_crit_celsius": 1,
+		"system_thermal_zone_crit_alarm":   1,
+	}
+	for name, n := range want {
+		if samples[name] != n {
+			t.Errorf("%s has %d samples, want %d", name, samples[name], n)
+		}
+	}
+}
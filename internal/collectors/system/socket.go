@@ -0,0 +1,239 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*socketCollector)(nil)
+
+// tcpStates maps the hexadecimal "st" column of /proc/net/tcp{,6} to the
+// state names used by ss and netstat.
+var tcpStates = map[string]string{
+	"01": "ESTABLISHED",
+	"02": "SYN_SENT",
+	"03": "SYN_RECV",
+	"04": "FIN_WAIT1",
+	"05": "FIN_WAIT2",
+	"06": "TIME_WAIT",
+	"07": "CLOSE",
+	"08": "CLOSE_WAIT",
+	"09": "LAST_ACK",
+	"0A": "LISTEN",
+	"0B": "CLOSING",
+	"0C": "NEW_SYN_RECV",
+}
+
+func NewSocketCollector(config *config.Usage) (prometheus.Collector, error) {
+	return &socketCollector{
+		config:         config,
+		tcpConnections: prometheus.NewDesc("system_socket_tcp_connections", "Number of TCP sockets by state", []string{"family", "state"}, nil),
+		udpSockets:     prometheus.NewDesc("system_socket_udp_sockets", "Number of UDP sockets", []string{"family"}, nil),
+		unixSockets:    prometheus.NewDesc("system_socket_unix_sockets", "Number of UNIX domain sockets", nil, nil),
+		socketsUsed:    prometheus.NewDesc("system_socket_used", "Number of sockets in use, from /proc/net/sockstat", nil, nil),
+		sockstat:       prometheus.NewDesc("system_socket_sockstat", "Socket counters by protocol from /proc/net/sockstat", []string{"protocol", "field"}, nil),
+		memoryPages:    prometheus.NewDesc("system_socket_memory_pages", "Memory pages used by sockets by protocol, from /proc/net/sockstat", []string{"protocol"}, nil),
+		listenQueue:    prometheus.NewDesc("system_socket_listen_queue_length", "Number of connections waiting in the accept queue of a listening TCP socket", []string{"family", "address", "port"}, nil),
+		listenBacklog:  prometheus.NewDesc("system_socket_listen_queue_max", "Maximum accept queue length (backlog) of a listening TCP socket", []string{"family", "address", "port"}, nil),
+		listeners:      prometheus.NewDesc("system_socket_listeners", "Number of listening TCP sockets bound to the address, more than one when they share it with SO_REUSEPORT", []string{"family", "address", "port"}, nil),
+	}, nil
+}
+
+type socketCollector struct {
+	config *config.Usage
+
+	tcpConnections *prometheus.Desc
+	udpSockets     *prometheus.Desc
+	unixSockets    *prometheus.Desc
+	socketsUsed    *prometheus.Desc
+	sockstat       *prometheus.Desc
+	memoryPages    *prometheus.Desc
+	listenQueue    *prometheus.Desc
+	listenBacklog  *prometheus.Desc
+	listeners      *prometheus.Desc
+}
+
+// listenSocket is the accept queue of a listening TCP socket, or the sum of
+// the queues of the sockets sharing its address.
+type listenSocket struct {
+	family  string
+	address string
+	port    string
+	queue   uint32
+	backlog uint32
+	// listeners is the number of sockets merged into this one.
+	listeners int
+}
+
+// Collect implements prometheus.Collector.
+func (c *socketCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting socket metrics")
+	if !c.config.Enabled {
+		slog.Warn("socket metrics are not enabled")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.GetTimeout())
+	defer cancel()
+
+	for family, file := range map[string]string{"ipv4": "tcp", "ipv6": "tcp6"} {
+		states, err := countTCPStates(procPath("net", file))
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				slog.Error("failed to get TCP sockets", "file", file, "error", err)
+			}
+			continue
+		}
+		for _, state := range tcpStates {
+			ch <- prometheus.MustNewConstMetric(c.tcpConnections, prometheus.GaugeValue, float64(states[state]), family, state)
+		}
+	}
+
+	for family, file := range map[string]string{"ipv4": "udp", "ipv6": "udp6"} {
+		count, err := countProcNetEntries(procPath("net", file))
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				slog.Error("failed to get UDP sockets", "file", file, "error", err)
+			}
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.udpSockets, prometheus.GaugeValue, float64(count), family)
+	}
+
+	if count, err := countProcNetEntries(procPath("net", "unix")); err != nil {
+		slog.Error("failed to get UNIX sockets", "error", err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.unixSockets, prometheus.GaugeValue, float64(count))
+	}
+
+	c.collectSockstat(ch)
+
+	listeners, err := listenSockets(ctx)
+	if err != nil {
+		if !errors.Is(err, errors.ErrUnsupported) {
+			slog.Error("failed to get listening sockets", "error", err)
+		}
+		return
+	}
+	for _, l := range mergeListenSockets(listeners) {
+		ch <- prometheus.MustNewConstMetric(c.listenQueue, prometheus.GaugeValue, float64(l.queue), l.family, l.address, l.port)
+		ch <- prometheus.MustNewConstMetric(c.listenBacklog, prometheus.GaugeValue, float64(l.backlog), l.family, l.address, l.port)
+		ch <- prometheus.MustNewConstMetric(c.listeners, prometheus.GaugeValue, float64(l.listeners), l.family, l.address, l.port)
+	}
+}
+
+// mergeListenSockets merges the sockets listening on the same address, which
+// SO_REUSEPORT allows, summing their queue lengths and backlogs so each
+// address is exported once.
+func mergeListenSockets(sockets []listenSocket) []listenSocket {
+	var merged []listenSocket
+	index := make(map[[3]string]int, len(sockets))
+	for _, socket := range sockets {
+		key := [3]string{socket.family, socket.address, socket.port}
+		if i, ok := index[key]; ok {
+			merged[i].queue += socket.queue
+			merged[i].backlog += socket.backlog
+			merged[i].listeners++
+			continue
+		}
+		index[key] = len(merged)
+		socket.listeners = 1
+		merged = append(merged, socket)
+	}
+	return merged
+}
+
+// collectSockstat exports /proc/net/sockstat and /proc/net/sockstat6, whose
+// lines look like "TCP: inuse 4 orphan 0 tw 2 alloc 4 mem 0".
+func (c *socketCollector) collectSockstat(ch chan<- prometheus.Metric) {
+	for _, file := range []string{"sockstat", "sockstat6"} {
+		data, err := os.ReadFile(procPath("net", file))
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				slog.Error("failed to get socket statistics", "file", file, "error", err)
+			}
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			protocol, rest, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			fields := strings.Fields(rest)
+			for i := 0; i+1 < len(fields); i += 2 {
+				value, err := strconv.ParseFloat(fields[i+1], 64)
+				if err != nil {
+					continue
+				}
+				switch {
+				case protocol == "sockets" && fields[i] == "used":
+					ch <- prometheus.MustNewConstMetric(c.socketsUsed, prometheus.GaugeValue, value)
+				case fields[i] == "mem":
+					ch <- prometheus.MustNewConstMetric(c.memoryPages, prometheus.GaugeValue, value, protocol)
+				default:
+					ch <- prometheus.MustNewConstMetric(c.sockstat, prometheus.GaugeValue, value, protocol, fields[i])
+				}
+			}
+		}
+	}
+}
+
+// countTCPStates counts the sockets of a /proc/net/tcp{,6} file by state.
+func countTCPStates(path string) (map[string]int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	states := make(map[string]int, len(tcpStates))
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // skip header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		if state, ok := tcpStates[fields[3]]; ok {
+			states[state]++
+		}
+	}
+	return states, scanner.Err()
+}
+
+// countProcNetEntries counts the entries of a /proc/net table with a header line.
+func countProcNetEntries(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	count := -1 // header
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		count++
+	}
+	return max(count, 0), scanner.Err()
+}
+
+// Describe implements prometheus.Collector.
+func (c *socketCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.tcpConnections
+	ch <- c.udpSockets
+	ch <- c.unixSockets
+	ch <- c.socketsUsed
+	ch <- c.sockstat
+	ch <- c.memoryPages
+	ch <- c.listenQueue
+	ch <- c.listenBacklog
+	ch <- c.listeners
+}
@@ -0,0 +1,114 @@
+package system
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Constants from linux/sock_diag.h and linux/inet_diag.h.
+const (
+	sockDiagByFamily = 20
+	tcpListenState   = 10
+	inetDiagReqLen   = 56
+	inetDiagMsgLen   = 72
+)
+
+// listenSockets dumps the listening TCP sockets over the NETLINK_INET_DIAG
+// protocol. Unlike /proc/net/tcp it reports the configured backlog next to
+// the current accept queue length, which is what "ss -lnt" shows as Send-Q
+// and Recv-Q.
+func listenSockets(ctx context.Context) ([]listenSocket, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, syscall.NETLINK_INET_DIAG)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open netlink socket: %w", err)
+	}
+	defer syscall.Close(fd)
+
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout := syscall.NsecToTimeval(max(time.Until(deadline), time.Millisecond).Nanoseconds())
+		if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &timeout); err != nil {
+			return nil, fmt.Errorf("failed to set netlink timeout: %w", err)
+		}
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("failed to bind netlink socket: %w", err)
+	}
+
+	var sockets []listenSocket
+	for _, family := range []uint8{syscall.AF_INET, syscall.AF_INET6} {
+		found, err := dumpListenSockets(fd, family)
+		if err != nil {
+			return nil, err
+		}
+		sockets = append(sockets, found...)
+	}
+	return sockets, nil
+}
+
+func dumpListenSockets(fd int, family uint8) ([]listenSocket, error) {
+	req := make([]byte, syscall.NLMSG_HDRLEN+inetDiagReqLen)
+	binary.NativeEndian.PutUint32(req[0:4], uint32(len(req)))
+	binary.NativeEndian.PutUint16(req[4:6], sockDiagByFamily)
+	binary.NativeEndian.PutUint16(req[6:8], syscall.NLM_F_REQUEST|syscall.NLM_F_DUMP)
+	binary.NativeEndian.PutUint32(req[8:12], uint32(family))
+	body := req[syscall.NLMSG_HDRLEN:]
+	body[0] = family
+	body[1] = syscall.IPPROTO_TCP
+	binary.NativeEndian.PutUint32(body[4:8], 1<<tcpListenState)
+	if err := syscall.Sendto(fd, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("failed to send netlink request: %w", err)
+	}
+
+	var sockets []listenSocket
+	buf := make([]byte, 32*1024)
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to receive netlink response: %w", err)
+		}
+		messages, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse netlink response: %w", err)
+		}
+		for _, m := range messages {
+			switch m.Header.Type {
+			case syscall.NLMSG_DONE:
+				return sockets, nil
+			case syscall.NLMSG_ERROR:
+				if len(m.Data) >= 4 {
+					if errno := int32(binary.NativeEndian.Uint32(m.Data[0:4])); errno != 0 {
+						return nil, fmt.Errorf("netlink request failed: %w", syscall.Errno(-errno))
+					}
+				}
+				return nil, errors.New("netlink request failed")
+			}
+			if len(m.Data) < inetDiagMsgLen {
+				continue
+			}
+			sockets = append(sockets, parseInetDiagMsg(m.Data))
+		}
+	}
+}
+
+// parseInetDiagMsg decodes a struct inet_diag_msg.
+func parseInetDiagMsg(data []byte) listenSocket {
+	socket := listenSocket{
+		port:    strconv.Itoa(int(binary.BigEndian.Uint16(data[4:6]))),
+		queue:   binary.NativeEndian.Uint32(data[56:60]),
+		backlog: binary.NativeEndian.Uint32(data[60:64]),
+	}
+	if data[0] == syscall.AF_INET {
+		socket.family = "ipv4"
+		socket.address = net.IP(data[8:12]).String()
+	} else {
+		socket.family = "ipv6"
+		socket.address = net.IP(data[8:24]).String()
+	}
+	return socket
+}
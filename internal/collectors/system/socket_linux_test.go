@@ -0,0 +1,111 @@
+package system
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"slices"
+	"syscall"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+// soReusePort is SO_REUSEPORT from asm-generic/socket.h, which the syscall
+// package does not define.
+const soReusePort = 15
+
+// inetDiagMsg builds a struct inet_diag_msg for a listening socket.
+func inetDiagMsg(family uint8, address string, port uint16, queue, backlog uint32) []byte {
+	data := make([]byte, inetDiagMsgLen)
+	data[0] = family
+	data[1] = tcpListenState
+	binary.BigEndian.PutUint16(data[4:6], port)
+	ip := net.ParseIP(address)
+	if family == syscall.AF_INET {
+		copy(data[8:12], ip.To4())
+	} else {
+		copy(data[8:24], ip.To16())
+	}
+	binary.NativeEndian.PutUint32(data[56:60], queue)
+	binary.NativeEndian.PutUint32(data[60:64], backlog)
+	return data
+}
+
+func TestListenSockets(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages [][]byte
+		want     []listenSocket
+	}{
+		{
+			name: "distinct addresses",
+			messages: [][]byte{
+				inetDiagMsg(syscall.AF_INET, "0.0.0.0", 22, 0, 128),
+				inetDiagMsg(syscall.AF_INET6, "::", 22, 1, 128),
+			},
+			want: []listenSocket{
+				{family: "ipv4", address: "0.0.0.0", port: "22", queue: 0, backlog: 128, listeners: 1},
+				{family: "ipv6", address: "::", port: "22", queue: 1, backlog: 128, listeners: 1},
+			},
+		},
+		{
+			name: "SO_REUSEPORT listeners",
+			messages: [][]byte{
+				inetDiagMsg(syscall.AF_INET, "127.0.0.1", 38124, 2, 4096),
+				inetDiagMsg(syscall.AF_INET, "127.0.0.1", 8080, 0, 511),
+				inetDiagMsg(syscall.AF_INET, "127.0.0.1", 38124, 3, 4096),
+			},
+			want: []listenSocket{
+				{family: "ipv4", address: "127.0.0.1", port: "38124", queue: 5, backlog: 8192, listeners: 2},
+				{family: "ipv4", address: "127.0.0.1", port: "8080", queue: 0, backlog: 511, listeners: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sockets []listenSocket
+			for _, message := range tt.messages {
+				sockets = append(sockets, parseInetDiagMsg(message))
+			}
+			if got := mergeListenSockets(sockets); !slices.Equal(got, tt.want) {
+				t.Errorf("mergeListenSockets() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSocketCollectorReusePort(t *testing.T) {
+	listenConfig := net.ListenConfig{Control: func(_, _ string, c syscall.RawConn) error {
+		var err error
+		c.Control(func(fd uintptr) {
+			err = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, soReusePort, 1)
+		})
+		return err
+	}}
+	first, err := listenConfig.Listen(context.Background(), "tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := listenConfig.Listen(context.Background(), "tcp4", first.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+	if _, err := listenSockets(context.Background()); err != nil {
+		t.Skipf("inet_diag is not available: %v", err)
+	}
+
+	collector, err := NewSocketCollector(&config.Usage{Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	if _, err := registry.Gather(); err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package system
+
+import (
+	"context"
+	"errors"
+)
+
+// listenSockets is only implemented on Linux.
+func listenSockets(_ context.Context) ([]listenSocket, error) {
+	return nil, errors.ErrUnsupported
+}
@@ -11,7 +11,27 @@ import (
 
 func NewSystemCollector(config *config.SystemCollectorConfig) []prometheus.Collector {
 	systemCollector := &SystemCollector{}
-	systemCollector.AppendCollector(NewCPUCollector, &config.CPUUsage)
+	systemCollector.AppendCollector(NewCPUCollector, &config.CPUUsage).
+		AppendCollector(NewMemoryCollector, &config.MemoryUsage).
+		AppendCollector(NewDiskCollector, &config.DiskUsage).
+		AppendCollector(NewDiskIOCollector, &config.DiskIOUsage).
+		AppendCollector(NewNetworkCollector, &config.NetworkUsage).
+		Append(NewProcessCollector(&config.ProcessUsage)).
+		Append(NewThreadCollector(&config.ThreadUsage)).
+		AppendCollector(NewSocketCollector, &config.SocketUsage).
+		Append(NewFileCollector(&config.FileUsage)).
+		AppendCollector(NewHostCollector, &config.HostUsage).
+		Append(NewPressureCollector(&config.PressureUsage)).
+		Append(NewCgroupCollector(&config.CgroupUsage)).
+		Append(NewSensorCollector(&config.SensorUsage)).
+		Append(NewNetstatCollector(&config.NetstatUsage)).
+		Append(NewVMStatCollector(&config.VMStatUsage)).
+		AppendCollector(NewInterruptCollector, &config.InterruptUsage).
+		AppendCollector(NewCPUFreqCollector, &config.CPUFreqUsage).
+		AppendCollector(NewRAIDCollector, &config.RAIDUsage).
+		AppendCollector(NewLinkCollector, &config.LinkUsage).
+		Append(NewTimeCollector(&config.TimeUsage)).
+		AppendCollector(NewConntrackCollector, &config.ConntrackUsage)
 	return systemCollector.collectors
 }
 
@@ -22,7 +42,12 @@ type SystemCollector struct {
 type CollectorFunc func(config *config.Usage) (prometheus.Collector, error)
 
 func (s *SystemCollector) AppendCollector(f CollectorFunc, config *config.Usage) *SystemCollector {
-	collector, err := f(config)
+	return s.Append(f(config))
+}
+
+// Append adds a collector built by a constructor that takes its own
+// configuration type, logging and skipping it when construction failed.
+func (s *SystemCollector) Append(collector prometheus.Collector, err error) *SystemCollector {
 	if err != nil {
 		slog.Warn("failed to create collector", "error", err)
 		return s
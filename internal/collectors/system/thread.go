@@ -0,0 +1,143 @@
+package system
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*threadCollector)(nil)
+
+// threadStates maps the scheduler state letters of /proc/<pid>/task/<tid>/stat
+// to the state label. Everything else is reported as "other".
+var threadStates = map[byte]string{
+	'R': "running",
+	'S': "sleeping",
+	'D': "uninterruptible",
+	'Z': "zombie",
+	'T': "stopped",
+	't': "stopped",
+	'I': "idle",
+}
+
+func NewThreadCollector(config *config.ThreadUsage) (prometheus.Collector, error) {
+	return &threadCollector{
+		config:         config,
+		threads:        prometheus.NewDesc("system_threads", "Number of threads in the system", nil, nil),
+		threadsByState: prometheus.NewDesc("system_threads_state", "Number of threads in the system by scheduler state", []string{"state"}, nil),
+		threadsMax:     prometheus.NewDesc("system_threads_max", "Maximum number of threads the kernel allows (kernel.threads-max)", nil, nil),
+		processThreads: prometheus.NewDesc("system_threads_top_process", "Number of threads of the processes with the most threads", []string{"pid", "name"}, nil),
+	}, nil
+}
+
+type threadCollector struct {
+	config *config.ThreadUsage
+
+	threads        *prometheus.Desc
+	threadsByState *prometheus.Desc
+	threadsMax     *prometheus.Desc
+	processThreads *prometheus.Desc
+}
+
+type processThreadCount struct {
+	pid     int
+	name    string
+	threads int
+}
+
+// Collect implements prometheus.Collector.
+func (c *threadCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting thread metrics")
+	if !c.config.Enabled {
+		slog.Warn("thread metrics are not enabled")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.GetTimeout())
+	defer cancel()
+
+	if threadsMax, err := readUintFile(procPath("sys", "kernel", "threads-max")); err != nil {
+		slog.Error("failed to get threads max", "error", err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.threadsMax, prometheus.GaugeValue, float64(threadsMax))
+	}
+
+	pids, err := listPIDs()
+	if err != nil {
+		slog.Error("failed to get processes", "error", err)
+		return
+	}
+
+	states := map[string]int{"other": 0}
+	for _, state := range threadStates {
+		states[state] = 0
+	}
+	total := 0
+	counts := make([]processThreadCount, 0, len(pids))
+	for _, pid := range pids {
+		if ctx.Err() != nil {
+			slog.Error("thread collection timed out", "error", ctx.Err())
+			return
+		}
+		counts = append(counts, countProcessThreads(pid, states))
+		total += counts[len(counts)-1].threads
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.threads, prometheus.GaugeValue, float64(total))
+	for state, count := range states {
+		ch <- prometheus.MustNewConstMetric(c.threadsByState, prometheus.GaugeValue, float64(count), state)
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].threads > counts[j].threads
+	})
+	for i, count := range counts {
+		if i >= c.config.GetTopN() || count.threads == 0 {
+			break
+		}
+		ch <- prometheus.MustNewConstMetric(c.processThreads, prometheus.GaugeValue, float64(count.threads), strconv.Itoa(count.pid), count.name)
+	}
+}
+
+// countProcessThreads walks the tasks of the process, adding their scheduler states
+// to states, and returns the thread count of the process.
+func countProcessThreads(pid int, states map[string]int) processThreadCount {
+	count := processThreadCount{pid: pid}
+	pidDir := strconv.Itoa(pid)
+	name, _, err := readProcStat(procPath(pidDir, "stat"))
+	if err != nil {
+		// The process exited while we were looking at it
+		return count
+	}
+	count.name = name
+
+	tids, err := listDirNames(procPath(pidDir, "task"))
+	if err != nil {
+		return count
+	}
+	for _, tid := range tids {
+		_, state, err := readProcStat(procPath(pidDir, "task", tid, "stat"))
+		if err != nil {
+			continue
+		}
+		count.threads++
+		if name, ok := threadStates[state]; ok {
+			states[name]++
+		} else {
+			states["other"]++
+		}
+	}
+	return count
+}
+
+// Describe implements prometheus.Collector.
+func (c *threadCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.threads
+	ch <- c.threadsByState
+	ch <- c.threadsMax
+	ch <- c.processThreads
+}
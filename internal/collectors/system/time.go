@@ -0,0 +1,204 @@
+package system
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*timeCollector)(nil)
+
+const (
+	// ntpEpochOffset is the number of seconds between the NTP epoch of 1900
+	// and the Unix epoch.
+	ntpEpochOffset = 2208988800
+	ntpPacketLen   = 48
+	ntpDefaultPort = "123"
+	// ntpLeapUnsync is the leap indicator of a server whose clock is not
+	// synchronised.
+	ntpLeapUnsync = 3
+)
+
+func NewTimeCollector(config *config.TimeUsage) (prometheus.Collector, error) {
+	serverLabels := []string{"server"}
+	return &timeCollector{
+		config:     config,
+		offset:     prometheus.NewDesc("system_timex_offset_seconds", "Offset between the local clock and the reference clock, as last measured by the NTP daemon", nil, nil),
+		frequency:  prometheus.NewDesc("system_timex_frequency_adjustment_ratio", "Frequency adjustment applied to the local clock", nil, nil),
+		maxError:   prometheus.NewDesc("system_timex_maxerror_seconds", "Maximum error of the local clock", nil, nil),
+		estError:   prometheus.NewDesc("system_timex_estimated_error_seconds", "Estimated error of the local clock", nil, nil),
+		synced:     prometheus.NewDesc("system_timex_sync_status", "Whether the kernel considers the local clock synchronised", nil, nil),
+		tai:        prometheus.NewDesc("system_timex_tai_offset_seconds", "Offset between International Atomic Time and UTC", nil, nil),
+		ntpSuccess: prometheus.NewDesc("system_ntp_success", "Whether the NTP server answered with a synchronised clock", serverLabels, nil),
+		ntpOffset:  prometheus.NewDesc("system_ntp_offset_seconds", "Offset of the local clock from the NTP server", serverLabels, nil),
+		ntpRTT:     prometheus.NewDesc("system_ntp_rtt_seconds", "Round trip time to the NTP server", serverLabels, nil),
+		ntpStratum: prometheus.NewDesc("system_ntp_stratum", "Stratum of the NTP server", serverLabels, nil),
+	}, nil
+}
+
+type timeCollector struct {
+	config *config.TimeUsage
+
+	offset     *prometheus.Desc
+	frequency  *prometheus.Desc
+	maxError   *prometheus.Desc
+	estError   *prometheus.Desc
+	synced     *prometheus.Desc
+	tai        *prometheus.Desc
+	ntpSuccess *prometheus.Desc
+	ntpOffset  *prometheus.Desc
+	ntpRTT     *prometheus.Desc
+	ntpStratum *prometheus.Desc
+}
+
+// timex is the state of the kernel clock discipline, in seconds.
+type timex struct {
+	offset    float64
+	frequency float64
+	maxError  float64
+	estError  float64
+	synced    bool
+	tai       float64
+}
+
+// ntpResponse is the measurement of one NTP query.
+type ntpResponse struct {
+	offset  time.Duration
+	rtt     time.Duration
+	stratum uint8
+	leap    uint8
+}
+
+// Collect implements prometheus.Collector.
+func (c *timeCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting time metrics")
+	if !c.config.Enabled {
+		slog.Warn("time metrics are not enabled")
+		return
+	}
+
+	tx, err := readTimex()
+	if err != nil {
+		if !errors.Is(err, errors.ErrUnsupported) {
+			slog.Error("failed to get kernel clock state", "error", err)
+		}
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.offset, prometheus.GaugeValue, tx.offset)
+		ch <- prometheus.MustNewConstMetric(c.frequency, prometheus.GaugeValue, tx.frequency)
+		ch <- prometheus.MustNewConstMetric(c.maxError, prometheus.GaugeValue, tx.maxError)
+		ch <- prometheus.MustNewConstMetric(c.estError, prometheus.GaugeValue, tx.estError)
+		ch <- prometheus.MustNewConstMetric(c.synced, prometheus.GaugeValue, boolToFloat(tx.synced))
+		ch <- prometheus.MustNewConstMetric(c.tai, prometheus.GaugeValue, tx.tai)
+	}
+
+	server := c.config.NTPServer
+	if server == "" {
+		return
+	}
+	resp, err := queryNTP(server, c.config.GetTimeout())
+	if err != nil {
+		slog.Error("failed to query NTP server", "server", server, "error", err)
+		ch <- prometheus.MustNewConstMetric(c.ntpSuccess, prometheus.GaugeValue, 0, server)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.ntpSuccess, prometheus.GaugeValue, boolToFloat(resp.leap != ntpLeapUnsync), server)
+	ch <- prometheus.MustNewConstMetric(c.ntpOffset, prometheus.GaugeValue, resp.offset.Seconds(), server)
+	ch <- prometheus.MustNewConstMetric(c.ntpRTT, prometheus.GaugeValue, resp.rtt.Seconds(), server)
+	ch <- prometheus.MustNewConstMetric(c.ntpStratum, prometheus.GaugeValue, float64(resp.stratum), server)
+}
+
+// queryNTP sends one SNTP client request to server and measures the offset of
+// the local clock from the four timestamps of the exchange as in RFC 4330.
+func queryNTP(server string, timeout time.Duration) (ntpResponse, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, ntpDefaultPort)
+	}
+	conn, err := net.DialTimeout("udp", server, timeout)
+	if err != nil {
+		return ntpResponse{}, err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return ntpResponse{}, err
+	}
+
+	req := make([]byte, ntpPacketLen)
+	// Leap indicator 0, version 4, client mode
+	req[0] = 0<<6 | 4<<3 | 3
+	sent := time.Now()
+	binary.BigEndian.PutUint64(req[40:], toNTPTime(sent))
+	if _, err := conn.Write(req); err != nil {
+		return ntpResponse{}, err
+	}
+
+	resp := make([]byte, ntpPacketLen)
+	for {
+		n, err := conn.Read(resp)
+		if err != nil {
+			return ntpResponse{}, err
+		}
+		received := time.Now()
+		// Skip stray packets that do not answer our request
+		if n < ntpPacketLen || resp[0]&0x7 != 4 || binary.BigEndian.Uint64(resp[24:]) != binary.BigEndian.Uint64(req[40:]) {
+			continue
+		}
+		stratum := resp[1]
+		if stratum == 0 {
+			return ntpResponse{}, fmt.Errorf("kiss of death %q", resp[12:16])
+		}
+		// A zero timestamp means the server does not know the time
+		if binary.BigEndian.Uint64(resp[40:]) == 0 {
+			return ntpResponse{}, errors.New("missing transmit timestamp")
+		}
+		serverReceived := fromNTPTime(binary.BigEndian.Uint64(resp[32:]))
+		serverSent := fromNTPTime(binary.BigEndian.Uint64(resp[40:]))
+		return ntpResponse{
+			offset:  (serverReceived.Sub(sent) + serverSent.Sub(received)) / 2,
+			rtt:     received.Sub(sent) - serverSent.Sub(serverReceived),
+			stratum: stratum,
+			leap:    resp[0] >> 6,
+		}, nil
+	}
+}
+
+// toNTPTime converts t to the 64-bit NTP timestamp format, seconds since
+// 1900 modulo 2^32 in the upper half and the fraction of a second in the
+// lower half.
+func toNTPTime(t time.Time) uint64 {
+	seconds := uint64(t.Unix()+ntpEpochOffset) & 0xffffffff
+	fraction := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	return seconds<<32 | fraction
+}
+
+// fromNTPTime converts a 64-bit NTP timestamp to a time. Seconds with the top
+// bit clear are taken to be in the era starting in 2036, when the 32-bit
+// seconds wrap around.
+func fromNTPTime(ts uint64) time.Time {
+	seconds := int64(ts>>32) - ntpEpochOffset
+	if ts>>63 == 0 {
+		seconds += 1 << 32
+	}
+	nanos := int64((ts & 0xffffffff) * uint64(time.Second) >> 32)
+	return time.Unix(seconds, nanos)
+}
+
+// Describe implements prometheus.Collector.
+func (c *timeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.offset
+	ch <- c.frequency
+	ch <- c.maxError
+	ch <- c.estError
+	ch <- c.synced
+	ch <- c.tai
+	ch <- c.ntpSuccess
+	ch <- c.ntpOffset
+	ch <- c.ntpRTT
+	ch <- c.ntpStratum
+}
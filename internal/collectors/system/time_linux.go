@@ -0,0 +1,34 @@
+package system
+
+import "syscall"
+
+// Constants from linux/timex.h.
+const (
+	timexStatusUnsync = 0x0040 // STA_UNSYNC
+	timexStatusNano   = 0x2000 // STA_NANO
+	timexStateError   = 5      // TIME_ERROR
+)
+
+// readTimex reads the state of the kernel clock discipline. adjtimex with no
+// modes set only reads it, so it needs no privileges.
+func readTimex() (timex, error) {
+	var buf syscall.Timex
+	state, err := syscall.Adjtimex(&buf)
+	if err != nil {
+		return timex{}, err
+	}
+	// The offset is in microseconds unless the NTP daemon switched the kernel
+	// to nanoseconds
+	offset := float64(buf.Offset) / 1e6
+	if buf.Status&timexStatusNano != 0 {
+		offset = float64(buf.Offset) / 1e9
+	}
+	return timex{
+		offset:    offset,
+		frequency: float64(buf.Freq) / 65536 / 1e6,
+		maxError:  float64(buf.Maxerror) / 1e6,
+		estError:  float64(buf.Esterror) / 1e6,
+		synced:    buf.Status&timexStatusUnsync == 0 && state != timexStateError,
+		tai:       float64(buf.Tai),
+	}, nil
+}
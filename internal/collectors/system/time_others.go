@@ -0,0 +1,10 @@
+//go:build !linux
+
+package system
+
+import "errors"
+
+// readTimex is only implemented on Linux.
+func readTimex() (timex, error) {
+	return timex{}, errors.ErrUnsupported
+}
@@ -0,0 +1,160 @@
+package system
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ntpServer answers each request read by a local UDP listener with the
+// packets built by reply from the request.
+func ntpServer(t *testing.T, reply func(req []byte) [][]byte) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, ntpPacketLen)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			for _, packet := range reply(buf[:n]) {
+				conn.WriteTo(packet, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// ntpPacket builds a server reply to req from a server whose clock is offset
+// ahead of the local one.
+func ntpPacket(req []byte, leap, stratum uint8, offset time.Duration) []byte {
+	resp := make([]byte, ntpPacketLen)
+	resp[0] = leap<<6 | 4<<3 | 4
+	resp[1] = stratum
+	copy(resp[24:32], req[40:48])
+	now := time.Now().Add(offset)
+	binary.BigEndian.PutUint64(resp[32:], toNTPTime(now))
+	binary.BigEndian.PutUint64(resp[40:], toNTPTime(now))
+	return resp
+}
+
+func TestQueryNTP(t *testing.T) {
+	server := ntpServer(t, func(req []byte) [][]byte {
+		return [][]byte{ntpPacket(req, 0, 2, 1500*time.Millisecond)}
+	})
+	resp, err := queryNTP(server, time.Second)
+	if err != nil {
+		t.Fatalf("queryNTP() error = %v", err)
+	}
+	if diff := resp.offset - 1500*time.Millisecond; diff < -50*time.Millisecond || diff > 50*time.Millisecond {
+		t.Errorf("offset = %v, want about 1.5s", resp.offset)
+	}
+	if resp.stratum != 2 {
+		t.Errorf("stratum = %d, want 2", resp.stratum)
+	}
+	if resp.leap != 0 {
+		t.Errorf("leap = %d, want 0", resp.leap)
+	}
+	if resp.rtt < 0 || resp.rtt > time.Second {
+		t.Errorf("rtt = %v, want between 0 and 1s", resp.rtt)
+	}
+}
+
+func TestQueryNTPUnsynchronised(t *testing.T) {
+	server := ntpServer(t, func(req []byte) [][]byte {
+		return [][]byte{ntpPacket(req, ntpLeapUnsync, 16, 0)}
+	})
+	resp, err := queryNTP(server, time.Second)
+	if err != nil {
+		t.Fatalf("queryNTP() error = %v", err)
+	}
+	if resp.leap != ntpLeapUnsync {
+		t.Errorf("leap = %d, want %d", resp.leap, ntpLeapUnsync)
+	}
+}
+
+func TestQueryNTPKissOfDeath(t *testing.T) {
+	server := ntpServer(t, func(req []byte) [][]byte {
+		resp := ntpPacket(req, ntpLeapUnsync, 0, 0)
+		copy(resp[12:16], "RATE")
+		return [][]byte{resp}
+	})
+	_, err := queryNTP(server, time.Second)
+	if err == nil || !strings.Contains(err.Error(), "RATE") {
+		t.Errorf("queryNTP() error = %v, want kiss of death RATE", err)
+	}
+}
+
+func TestQueryNTPMissingTransmitTimestamp(t *testing.T) {
+	server := ntpServer(t, func(req []byte) [][]byte {
+		resp := ntpPacket(req, 0, 2, 0)
+		clear(resp[40:48])
+		return [][]byte{resp}
+	})
+	if _, err := queryNTP(server, time.Second); err == nil {
+		t.Error("queryNTP() accepted a reply without a transmit timestamp")
+	}
+}
+
+func TestQueryNTPOriginMismatch(t *testing.T) {
+	stray := func(req []byte) []byte {
+		resp := ntpPacket(req, 0, 1, 0)
+		binary.BigEndian.PutUint64(resp[24:], binary.BigEndian.Uint64(req[40:])+1)
+		return resp
+	}
+
+	t.Run("only stray packets", func(t *testing.T) {
+		server := ntpServer(t, func(req []byte) [][]byte {
+			return [][]byte{stray(req)}
+		})
+		if _, err := queryNTP(server, 200*time.Millisecond); err == nil {
+			t.Error("queryNTP() accepted a reply to another request")
+		}
+	})
+	t.Run("stray packet before the reply", func(t *testing.T) {
+		server := ntpServer(t, func(req []byte) [][]byte {
+			return [][]byte{stray(req), ntpPacket(req, 0, 3, 0)}
+		})
+		resp, err := queryNTP(server, time.Second)
+		if err != nil {
+			t.Fatalf("queryNTP() error = %v", err)
+		}
+		if resp.stratum != 3 {
+			t.Errorf("stratum = %d, want 3 from the matching reply", resp.stratum)
+		}
+	})
+}
+
+func TestNTPTimeRoundTrip(t *testing.T) {
+	tests := []time.Time{
+		time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 17, 12, 34, 56, 789012345, time.UTC),
+		// The last second of NTP era 0 and the first of era 1
+		time.Date(2036, 2, 7, 6, 28, 15, 999999999, time.UTC),
+		time.Date(2036, 2, 7, 6, 28, 16, 0, time.UTC),
+		time.Date(2036, 2, 7, 6, 28, 16, 500000000, time.UTC),
+		time.Date(2050, 6, 1, 0, 0, 0, 1, time.UTC),
+	}
+	for _, want := range tests {
+		ts := toNTPTime(want)
+		got := fromNTPTime(ts)
+		// The 32-bit fraction is coarser than a nanosecond
+		if diff := got.Sub(want); diff < -time.Nanosecond || diff > time.Nanosecond {
+			t.Errorf("fromNTPTime(toNTPTime(%v)) = %v", want, got.UTC())
+		}
+	}
+
+	if seconds := toNTPTime(time.Date(2036, 2, 7, 6, 28, 16, 0, time.UTC)) >> 32; seconds != 0 {
+		t.Errorf("seconds at the start of era 1 = %d, want 0", seconds)
+	}
+	if got := fromNTPTime(ntpEpochOffset << 32); !got.Equal(time.Unix(0, 0)) {
+		t.Errorf("fromNTPTime(unix epoch) = %v", got.UTC())
+	}
+}
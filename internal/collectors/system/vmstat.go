@@ -0,0 +1,206 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ prometheus.Collector = (*vmstatCollector)(nil)
+
+// defaultVMStatFields are exported when no fields are configured.
+var defaultVMStatFields = []string{
+	`^(pgfault|pgmajfault|pgpgin|pgpgout|pswpin|pswpout|oom_kill)$`,
+	`^(compact_stall|compact_fail|compact_success|allocstall_.*)$`,
+	`^(pgscan|pgsteal)_(kswapd|direct)$`,
+	`^thp_(fault_alloc|fault_fallback|collapse_alloc)$`,
+}
+
+// hugepagesDirPattern matches the per size directories below
+// /sys/kernel/mm/hugepages, e.g. hugepages-2048kB.
+var hugepagesDirPattern = regexp.MustCompile(`^hugepages-(\d+)kB$`)
+
+func NewVMStatCollector(config *config.VMStatUsage) (prometheus.Collector, error) {
+	fields := config.Fields
+	if len(fields) == 0 {
+		fields = defaultVMStatFields
+	}
+	patterns, err := compilePatterns(fields)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vmstat field pattern: %w", err)
+	}
+	hugepageLabels := []string{"size"}
+	return &vmstatCollector{
+		config:            config,
+		patterns:          patterns,
+		counter:           prometheus.NewDesc("system_vmstat_total", "Kernel virtual memory event counter from /proc/vmstat", []string{"field"}, nil),
+		gauge:             prometheus.NewDesc("system_vmstat", "Kernel virtual memory current value from /proc/vmstat", []string{"field"}, nil),
+		hugepages:         prometheus.NewDesc("system_hugepages", "Number of persistent huge pages in the pool", hugepageLabels, nil),
+		hugepagesFree:     prometheus.NewDesc("system_hugepages_free", "Number of huge pages not yet allocated", hugepageLabels, nil),
+		hugepagesReserved: prometheus.NewDesc("system_hugepages_reserved", "Number of huge pages committed to but not yet faulted in", hugepageLabels, nil),
+		hugepagesSurplus:  prometheus.NewDesc("system_hugepages_surplus", "Number of huge pages allocated above the persistent pool", hugepageLabels, nil),
+		numaMemory:        prometheus.NewDesc("system_numa_memory_bytes", "Memory of the NUMA node by meminfo field", []string{"node", "field"}, nil),
+		numaEvents:        prometheus.NewDesc("system_numa_events_total", "NUMA allocation events of the node, e.g. numa_hit and numa_miss", []string{"node", "event"}, nil),
+	}, nil
+}
+
+type vmstatCollector struct {
+	config   *config.VMStatUsage
+	patterns []*regexp.Regexp
+
+	counter           *prometheus.Desc
+	gauge             *prometheus.Desc
+	hugepages         *prometheus.Desc
+	hugepagesFree     *prometheus.Desc
+	hugepagesReserved *prometheus.Desc
+	hugepagesSurplus  *prometheus.Desc
+	numaMemory        *prometheus.Desc
+	numaEvents        *prometheus.Desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *vmstatCollector) Collect(ch chan<- prometheus.Metric) {
+	slog.Info("collecting vmstat metrics")
+	if !c.config.Enabled {
+		slog.Warn("vmstat metrics are not enabled")
+		return
+	}
+
+	vmstat, err := readKeyedFile(procPath("vmstat"))
+	if err != nil {
+		slog.Error("failed to get vmstat", "error", err)
+	}
+	for field, value := range vmstat {
+		if !c.selected(field) {
+			continue
+		}
+		// nr_* fields are current page counts, the others count events
+		if strings.HasPrefix(field, "nr_") {
+			ch <- prometheus.MustNewConstMetric(c.gauge, prometheus.GaugeValue, value, field)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.counter, prometheus.CounterValue, value, field)
+	}
+
+	c.collectHugepages(ch)
+	c.collectNUMA(ch)
+}
+
+func (c *vmstatCollector) selected(field string) bool {
+	for _, re := range c.patterns {
+		if re.MatchString(field) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *vmstatCollector) collectHugepages(ch chan<- prometheus.Metric) {
+	dir := sysPath("kernel", "mm", "hugepages")
+	entries, err := listDirNames(dir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Error("failed to get hugepages", "error", err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		m := hugepagesDirPattern.FindStringSubmatch(entry)
+		if m == nil {
+			continue
+		}
+		kb, _ := strconv.ParseUint(m[1], 10, 64)
+		size := strconv.FormatUint(kb*1024, 10)
+		for file, desc := range map[string]*prometheus.Desc{
+			"nr_hugepages":      c.hugepages,
+			"free_hugepages":    c.hugepagesFree,
+			"resv_hugepages":    c.hugepagesReserved,
+			"surplus_hugepages": c.hugepagesSurplus,
+		} {
+			value, err := readUintFile(filepath.Join(dir, entry, file))
+			if err != nil {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(value), size)
+		}
+	}
+}
+
+func (c *vmstatCollector) collectNUMA(ch chan<- prometheus.Metric) {
+	dir := sysPath("devices", "system", "node")
+	entries, err := listDirNames(dir)
+	if err != nil {
+		// Kernels built without NUMA support have no node directory
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Error("failed to get NUMA nodes", "error", err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		node, ok := strings.CutPrefix(entry, "node")
+		if !ok {
+			continue
+		}
+		if _, err := strconv.Atoi(node); err != nil {
+			continue
+		}
+		meminfo, err := readNodeMeminfo(filepath.Join(dir, entry, "meminfo"))
+		if err != nil {
+			slog.Error("failed to get NUMA node meminfo", "node", node, "error", err)
+		}
+		for field, value := range meminfo {
+			ch <- prometheus.MustNewConstMetric(c.numaMemory, prometheus.GaugeValue, value, node, field)
+		}
+		numastat, err := readKeyedFile(filepath.Join(dir, entry, "numastat"))
+		if err != nil {
+			slog.Error("failed to get NUMA node numastat", "node", node, "error", err)
+		}
+		for event, value := range numastat {
+			ch <- prometheus.MustNewConstMetric(c.numaEvents, prometheus.CounterValue, value, node, event)
+		}
+	}
+}
+
+// readNodeMeminfo parses the meminfo file of a NUMA node, e.g.
+// "Node 0 MemFree: 3770220 kB", returning the fields given in kB as bytes.
+// The unitless HugePages_* counts are skipped.
+func readNodeMeminfo(path string) (map[string]float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]float64)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 5 || fields[4] != "kB" {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed value for %s in %s: %w", fields[2], path, err)
+		}
+		values[strings.TrimSuffix(fields[2], ":")] = value * 1024
+	}
+	return values, nil
+}
+
+// Describe implements prometheus.Collector.
+func (c *vmstatCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.counter
+	ch <- c.gauge
+	ch <- c.hugepages
+	ch <- c.hugepagesFree
+	ch <- c.hugepagesReserved
+	ch <- c.hugepagesSurplus
+	ch <- c.numaMemory
+	ch <- c.numaEvents
+}
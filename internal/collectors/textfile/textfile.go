@@ -0,0 +1,117 @@
+// Package textfile provides the textfile collector, which publishes metrics
+// written by other programs as *.prom files.
+package textfile
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/laurel/internal/collectors/promtext"
+	"github.com/aide-family/laurel/internal/config"
+)
+
+var _ promtext.Source = (*textfileCollector)(nil)
+
+const (
+	mtimeName      = "textfile_mtime_seconds"
+	parseErrorName = "textfile_parse_error"
+)
+
+// NewTextfileCollector creates the textfile collector. Files defining a metric
+// for which reserved returns true are rejected, so they cannot clash with the
+// metrics of the built-in collectors.
+func NewTextfileCollector(config *config.TextfileCollectorConfig, reserved func(name string) bool) (promtext.Source, error) {
+	return &textfileCollector{
+		config:     config,
+		reserved:   reserved,
+		mtime:      prometheus.NewDesc(mtimeName, "Modification time of the textfile since unix epoch in seconds", []string{"file"}, nil),
+		parseError: prometheus.NewDesc(parseErrorName, "Whether the textfile could not be parsed or was rejected", []string{"file"}, nil),
+	}, nil
+}
+
+type textfileCollector struct {
+	config   *config.TextfileCollectorConfig
+	reserved func(name string) bool
+
+	mtime      *prometheus.Desc
+	parseError *prometheus.Desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *textfileCollector) Collect(ch chan<- prometheus.Metric) {
+	merger := promtext.NewMerger()
+	c.CollectFamilies(ch, merger)
+	merger.Collect(ch)
+}
+
+// CollectFamilies implements promtext.Source.
+func (c *textfileCollector) CollectFamilies(ch chan<- prometheus.Metric, merger *promtext.Merger) {
+	slog.Info("collecting textfile metrics")
+	if !c.config.Enabled {
+		slog.Warn("textfile metrics are not enabled")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.GetTimeout())
+	defer cancel()
+
+	for _, dir := range c.config.Directories {
+		files, err := filepath.Glob(filepath.Join(dir, "*.prom"))
+		if err != nil {
+			slog.Error("failed to list textfiles", "directory", dir, "error", err)
+			continue
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			if ctx.Err() != nil {
+				slog.Error("textfile collection timed out", "error", ctx.Err())
+				return
+			}
+			info, err := os.Stat(file)
+			if err != nil {
+				slog.Warn("failed to stat textfile", "file", file, "error", err)
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(c.mtime, prometheus.GaugeValue, float64(info.ModTime().UnixNano())/1e9, file)
+
+			if err := c.merge(file, merger); err != nil {
+				slog.Warn("failed to read textfile", "file", file, "error", err)
+				ch <- prometheus.MustNewConstMetric(c.parseError, prometheus.GaugeValue, 1, file)
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(c.parseError, prometheus.GaugeValue, 0, file)
+		}
+	}
+}
+
+// merge parses the file and adds its families to merger. The file is
+// rejected as a whole when it redefines a reserved metric, changes the type
+// or help of a family defined elsewhere, or repeats a sample.
+func (c *textfileCollector) merge(file string, merger *promtext.Merger) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	parsed, err := promtext.Parse(f)
+	if err != nil {
+		return err
+	}
+	for name := range parsed {
+		if c.reserved != nil && c.reserved(name) {
+			return fmt.Errorf("metric %s conflicts with a built-in metric", name)
+		}
+	}
+	return merger.Add(parsed)
+}
+
+// Describe implements prometheus.Collector. The metrics depend on the files
+// present at scrape time, so the collector is registered unchecked.
+func (c *textfileCollector) Describe(ch chan<- *prometheus.Desc) {
+}
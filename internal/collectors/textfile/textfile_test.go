@@ -0,0 +1,150 @@
+package textfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/aide-family/laurel/internal/config"
+)
+
+func TestTextfileCollector(t *testing.T) {
+	tests := []struct {
+		name string
+		// files maps file names to their content, read in name order
+		files map[string]string
+		// want maps the expected families to their number of samples
+		want map[string]int
+		// parseErrors are the files expected to be rejected
+		parseErrors []string
+	}{
+		{
+			name: "families merged across files",
+			files: map[string]string{
+				"a.prom": "# HELP backup_size_bytes Size of the last backup.\n# TYPE backup_size_bytes gauge\nbackup_size_bytes{job=\"db\"} 100\n",
+				"b.prom": "# TYPE backup_size_bytes gauge\nbackup_size_bytes{job=\"home\"} 200\n",
+			},
+			want: map[string]int{"backup_size_bytes": 2},
+		},
+		{
+			name: "invalid file",
+			files: map[string]string{
+				"a.prom": "backup_size_bytes 100\n",
+				"b.prom": "backup_size_bytes{job=\"db\" 100\n",
+			},
+			want:        map[string]int{"backup_size_bytes": 1},
+			parseErrors: []string{"b.prom"},
+		},
+		{
+			name: "type differs from an earlier file",
+			files: map[string]string{
+				"a.prom": "# TYPE backups_total counter\nbackups_total 3\n",
+				"b.prom": "# TYPE backups_total gauge\nbackups_total{job=\"home\"} 1\nbackup_size_bytes 100\n",
+			},
+			want:        map[string]int{"backups_total": 1},
+			parseErrors: []string{"b.prom"},
+		},
+		{
+			name: "help differs from an earlier file",
+			files: map[string]string{
+				"a.prom": "# HELP backups_total Backups run.\nbackups_total 3\n",
+				"b.prom": "# HELP backups_total Backups done.\nbackups_total{job=\"home\"} 1\n",
+			},
+			want:        map[string]int{"backups_total": 1},
+			parseErrors: []string{"b.prom"},
+		},
+		{
+			name: "sample repeated across files",
+			files: map[string]string{
+				"a.prom": "backups_total{job=\"db\"} 3\n",
+				"b.prom": "backups_total{job=\"db\"} 4\n",
+			},
+			want:        map[string]int{"backups_total": 1},
+			parseErrors: []string{"b.prom"},
+		},
+		{
+			name: "sample repeated within a file",
+			files: map[string]string{
+				"a.prom": "backups_total{job=\"db\"} 3\nbackup_size_bytes 100\nbackups_total{job=\"db\"} 4\n",
+			},
+			parseErrors: []string{"a.prom"},
+		},
+		{
+			name: "reserved metric",
+			files: map[string]string{
+				"a.prom": "system_load1 42\n",
+			},
+			parseErrors: []string{"a.prom"},
+		},
+		{
+			name: "textfile metric",
+			files: map[string]string{
+				"a.prom": "textfile_mtime_seconds{file=\"x\"} 1\n",
+			},
+			parseErrors: []string{"a.prom"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			collector, err := NewTextfileCollector(&config.TextfileCollectorConfig{
+				Usage:       config.Usage{Enabled: true},
+				Directories: []string{dir},
+			}, func(name string) bool { return strings.HasPrefix(name, "system_") })
+			if err != nil {
+				t.Fatal(err)
+			}
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(collector)
+			families, err := registry.Gather()
+			if err != nil {
+				t.Fatalf("Gather() error = %v", err)
+			}
+
+			got := make(map[string]*dto.MetricFamily)
+			for _, family := range families {
+				got[family.GetName()] = family
+			}
+			for name, family := range got {
+				if name == mtimeName || name == parseErrorName {
+					continue
+				}
+				if n := len(family.GetMetric()); n != tt.want[name] {
+					t.Errorf("%s has %d samples, want %d", name, n, tt.want[name])
+				}
+			}
+			for name, samples := range tt.want {
+				if _, ok := got[name]; !ok && samples > 0 {
+					t.Errorf("%s is missing", name)
+				}
+			}
+
+			rejected := make(map[string]bool)
+			for _, file := range tt.parseErrors {
+				rejected[filepath.Join(dir, file)] = true
+			}
+			for _, metric := range got[parseErrorName].GetMetric() {
+				file := metric.GetLabel()[0].GetValue()
+				want := 0.0
+				if rejected[file] {
+					want = 1
+				}
+				if value := metric.GetGauge().GetValue(); value != want {
+					t.Errorf("textfile_parse_error{file=%q} = %v, want %v", file, value, want)
+				}
+			}
+			if n := len(got[parseErrorName].GetMetric()); n != len(tt.files) {
+				t.Errorf("textfile_parse_error has %d samples, want %d", n, len(tt.files))
+			}
+		})
+	}
+}
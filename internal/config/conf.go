@@ -18,19 +18,295 @@ func (u *Usage) GetTimeout() time.Duration {
 
 // SystemCollectorConfig is the configuration for the system collector.
 type SystemCollectorConfig struct {
-	CPUUsage     Usage `yaml:"cpu_usage"`
-	MemoryUsage  Usage `yaml:"memory_usage"`
-	DiskUsage    Usage `yaml:"disk_usage"`
-	NetworkUsage Usage `yaml:"network_usage"`
-	ProcessUsage Usage `yaml:"process_usage"`
-	ThreadUsage  Usage `yaml:"thread_usage"`
-	SocketUsage  Usage `yaml:"socket_usage"`
-	FileUsage    Usage `yaml:"file_usage"`
+	CPUUsage       Usage         `yaml:"cpu_usage"`
+	MemoryUsage    Usage         `yaml:"memory_usage"`
+	DiskUsage      Usage         `yaml:"disk_usage"`
+	DiskIOUsage    Usage         `yaml:"disk_io"`
+	NetworkUsage   Usage         `yaml:"network_usage"`
+	ProcessUsage   ProcessUsage  `yaml:"process_usage"`
+	ThreadUsage    ThreadUsage   `yaml:"thread_usage"`
+	SocketUsage    Usage         `yaml:"socket_usage"`
+	FileUsage      FileUsage     `yaml:"file_usage"`
+	HostUsage      Usage         `yaml:"host_usage"`
+	PressureUsage  PressureUsage `yaml:"pressure_usage"`
+	CgroupUsage    CgroupUsage   `yaml:"cgroup_usage"`
+	SensorUsage    SensorUsage   `yaml:"sensor_usage"`
+	NetstatUsage   NetstatUsage  `yaml:"netstat_usage"`
+	VMStatUsage    VMStatUsage   `yaml:"vmstat_usage"`
+	InterruptUsage Usage         `yaml:"interrupt_usage"`
+	CPUFreqUsage   Usage         `yaml:"cpufreq_usage"`
+	RAIDUsage      Usage         `yaml:"raid_usage"`
+	LinkUsage      Usage         `yaml:"link_usage"`
+	TimeUsage      TimeUsage     `yaml:"time_usage"`
+	ConntrackUsage Usage         `yaml:"conntrack_usage"`
+}
+
+// ProcessUsage is the configuration for the process group collector.
+type ProcessUsage struct {
+	Usage  `yaml:",inline"`
+	Groups []ProcessGroup `yaml:"groups"`
+}
+
+// ProcessGroup names a set of processes. A process belongs to the first group
+// whose every non-empty matcher matches it.
+type ProcessGroup struct {
+	Name string `yaml:"name"`
+	// Exe matches the executable base name or full path.
+	Exe []string `yaml:"exe"`
+	// Cmdline is a regular expression matched against the full command line.
+	Cmdline string `yaml:"cmdline"`
+	// User matches the name of the user owning the process.
+	User []string `yaml:"user"`
+	// Cgroup is a regular expression matched against the process cgroup paths.
+	Cgroup string `yaml:"cgroup"`
+}
+
+// ThreadUsage is the configuration for the thread collector.
+type ThreadUsage struct {
+	Usage `yaml:",inline"`
+	// TopN is the number of processes with the most threads to export.
+	TopN int `yaml:"top_n"`
+}
+
+func (t *ThreadUsage) GetTopN() int {
+	if t.TopN <= 0 {
+		return 10
+	}
+	return t.TopN
+}
+
+// FileUsage is the configuration for the file descriptor collector.
+type FileUsage struct {
+	Usage `yaml:",inline"`
+	// TopN is the number of processes with the most open files to export.
+	TopN int `yaml:"top_n"`
+	// Groups selects processes whose open files are always exported.
+	Groups []ProcessGroup `yaml:"groups"`
+}
+
+func (f *FileUsage) GetTopN() int {
+	if f.TopN <= 0 {
+		return 10
+	}
+	return f.TopN
+}
+
+// PressureUsage is the configuration for the pressure stall information collector.
+type PressureUsage struct {
+	Usage `yaml:",inline"`
+	// Cgroups lists cgroup v2 paths, relative to the hierarchy root, whose
+	// pressure is exported in addition to the host level pressure.
+	Cgroups []string `yaml:"cgroups"`
+}
+
+// CgroupUsage is the configuration for the cgroup v2 collector.
+type CgroupUsage struct {
+	Usage `yaml:",inline"`
+	// Depth limits how many levels below the hierarchy root are walked.
+	Depth int `yaml:"depth"`
+	// Include and Exclude are regular expressions matched against the cgroup
+	// path. A cgroup is collected when it matches any include pattern, or no
+	// include pattern is configured, and matches no exclude pattern.
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+func (c *CgroupUsage) GetDepth() int {
+	if c.Depth <= 0 {
+		return 4
+	}
+	return c.Depth
+}
+
+// SensorUsage is the configuration for the hardware sensors collector.
+type SensorUsage struct {
+	Usage `yaml:",inline"`
+	// SysfsRoot is where sysfs is mounted, e.g. /host/sys inside a container.
+	// It defaults to HOST_SYS or /sys.
+	SysfsRoot string `yaml:"sysfs_root"`
+}
+
+// NetstatUsage is the configuration for the kernel protocol counters
+// collector.
+type NetstatUsage struct {
+	Usage `yaml:",inline"`
+	// Fields are regular expressions matched against <protocol>_<field>,
+	// e.g. Tcp_RetransSegs or TcpExt_ListenDrops. A field is exported when
+	// it matches any of them. A default set of commonly alerted on fields is
+	// used when empty.
+	Fields []string `yaml:"fields"`
+}
+
+// VMStatUsage is the configuration for the vmstat, hugepages and NUMA
+// collector.
+type VMStatUsage struct {
+	Usage `yaml:",inline"`
+	// Fields are regular expressions matched against the /proc/vmstat field
+	// names. A field is exported when it matches any of them. A default set
+	// covering paging, swapping, OOM kills and compaction is used when empty.
+	Fields []string `yaml:"fields"`
+}
+
+// TimeUsage is the configuration for the time synchronisation collector.
+type TimeUsage struct {
+	Usage `yaml:",inline"`
+	// NTPServer is queried on every scrape to measure the offset of the local
+	// clock, e.g. pool.ntp.org or 10.0.0.1:123. No server is queried when
+	// empty, leaving only the kernel's own view from adjtimex.
+	NTPServer string `yaml:"ntp_server"`
+}
+
+// TextfileCollectorConfig is the configuration for the textfile collector.
+type TextfileCollectorConfig struct {
+	Usage `yaml:",inline"`
+	// Directories are scanned for *.prom files on every scrape.
+	Directories []string `yaml:"directories"`
+}
+
+// ExecCollectorConfig is the configuration for the exec collector.
+type ExecCollectorConfig struct {
+	Usage    `yaml:",inline"`
+	Commands []ExecCommand `yaml:"commands"`
+}
+
+// ExecCommand is a command whose standard output is turned into metrics.
+type ExecCommand struct {
+	Name    string   `yaml:"name"`
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args"`
+	Dir     string   `yaml:"dir"`
+	// Env holds KEY=VALUE pairs added to the environment of laurel.
+	Env []string `yaml:"env"`
+	// Timeout overrides the collector timeout for this command.
+	Timeout time.Duration `yaml:"timeout"`
+	// Interval is the minimum time between two runs. Scrapes in between
+	// report the previous result. Zero runs the command on every scrape.
+	Interval time.Duration `yaml:"interval"`
+	// Format is the output format: prometheus, keyvalue or json.
+	Format string `yaml:"format"`
+}
+
+// ProbeCollectorConfig is the configuration for the probe collector.
+type ProbeCollectorConfig struct {
+	Usage   `yaml:",inline"`
+	Targets []Probe `yaml:"targets"`
+}
+
+// Probe is an endpoint checked on every scrape.
+type Probe struct {
+	Name string `yaml:"name"`
+	// Type is the kind of probe: http, tcp or dns.
+	Type string `yaml:"type"`
+	// Target is the URL for http, host:port for tcp and the name to look
+	// up for dns probes.
+	Target string `yaml:"target"`
+	// Timeout overrides the collector timeout for this probe.
+	Timeout time.Duration `yaml:"timeout"`
+	HTTP    HTTPProbe     `yaml:"http"`
+	TCP     TCPProbe      `yaml:"tcp"`
+	DNS     DNSProbe      `yaml:"dns"`
+}
+
+// HTTPProbe holds the settings of an http probe.
+type HTTPProbe struct {
+	// Method defaults to GET.
+	Method  string            `yaml:"method"`
+	Headers map[string]string `yaml:"headers"`
+	// ValidStatusCodes defaults to any 2xx status.
+	ValidStatusCodes []int `yaml:"valid_status_codes"`
+	// BodyRegex is a regular expression the response body must match.
+	BodyRegex          string `yaml:"body_regex"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// TCPProbe holds the settings of a tcp probe.
+type TCPProbe struct {
+	TLS                bool `yaml:"tls"`
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+	// Send is written to the connection once it is established.
+	Send string `yaml:"send"`
+	// Expect is a regular expression the data read from the connection must
+	// match, with ^ and $ matching at line boundaries.
+	Expect string `yaml:"expect"`
+}
+
+// DNSProbe holds the settings of a dns probe.
+type DNSProbe struct {
+	// QueryType is A, AAAA, CNAME, MX, NS or TXT, defaults to A.
+	QueryType string `yaml:"query_type"`
+	// Server is the host:port of the name server, defaults to the system
+	// resolver.
+	Server string `yaml:"server"`
+}
+
+// LogTailCollectorConfig is the configuration for the log tail collector.
+type LogTailCollectorConfig struct {
+	Usage `yaml:",inline"`
+	// StateFile keeps the read offsets across restarts, so lines are not
+	// counted twice. Empty disables it.
+	StateFile string    `yaml:"state_file"`
+	Files     []LogFile `yaml:"files"`
+}
+
+// LogFile is a log file followed across rotations.
+type LogFile struct {
+	Path  string    `yaml:"path"`
+	Rules []LogRule `yaml:"rules"`
+}
+
+// LogRule turns matching lines into a metric. The named capture groups of
+// the regex, other than Value, become labels.
+type LogRule struct {
+	Name  string `yaml:"name"`
+	Help  string `yaml:"help"`
+	Regex string `yaml:"regex"`
+	// Type is counter or histogram, defaults to counter.
+	Type string `yaml:"type"`
+	// Value is the capture group holding the number a counter is increased
+	// by or a histogram observes. Counters count matching lines when it is
+	// empty.
+	Value string `yaml:"value"`
+	// Buckets defaults to the Prometheus default buckets.
+	Buckets []float64 `yaml:"buckets"`
+}
+
+// FileWatchCollectorConfig is the configuration for the file watch
+// collector.
+type FileWatchCollectorConfig struct {
+	Usage   `yaml:",inline"`
+	Watches []FileWatch `yaml:"watches"`
+}
+
+// FileWatch is a set of files expected to be refreshed regularly, e.g. the
+// dumps written by a nightly backup.
+type FileWatch struct {
+	Name string `yaml:"name"`
+	// Paths are glob patterns. Matching directories are walked and their
+	// files included.
+	Paths []string `yaml:"paths"`
+	// MaxAge is the age above which the newest file is stale. Zero never
+	// reports the files as stale, unless none match.
+	MaxAge time.Duration `yaml:"max_age"`
+}
+
+// CertificateCollectorConfig is the configuration for the certificate
+// collector.
+type CertificateCollectorConfig struct {
+	Usage `yaml:",inline"`
+	// Paths are PEM or DER files, directories or glob patterns. Directories
+	// are scanned for *.pem, *.crt, *.cer, *.cert and *.der files.
+	Paths []string `yaml:"paths"`
 }
 
 type Config struct {
-	Server                ServerConfig          `yaml:"server"`
-	SystemCollectorConfig SystemCollectorConfig `yaml:"system_collector"`
+	Server                     ServerConfig               `yaml:"server"`
+	SystemCollectorConfig      SystemCollectorConfig      `yaml:"system_collector"`
+	TextfileCollectorConfig    TextfileCollectorConfig    `yaml:"textfile_collector"`
+	ExecCollectorConfig        ExecCollectorConfig        `yaml:"exec"`
+	ProbeCollectorConfig       ProbeCollectorConfig       `yaml:"probes"`
+	LogTailCollectorConfig     LogTailCollectorConfig     `yaml:"log_tail"`
+	FileWatchCollectorConfig   FileWatchCollectorConfig   `yaml:"file_watch"`
+	CertificateCollectorConfig CertificateCollectorConfig `yaml:"certificates"`
 }
 
 // ServerConfig defines the HTTP server configuration
@@ -4,14 +4,25 @@ package core
 import (
 	"context"
 	"log/slog"
+	"maps"
 	"net/http"
+	"regexp"
 
+	"github.com/aide-family/laurel/internal/collectors/certificate"
+	"github.com/aide-family/laurel/internal/collectors/exec"
+	"github.com/aide-family/laurel/internal/collectors/filewatch"
+	"github.com/aide-family/laurel/internal/collectors/logtail"
+	"github.com/aide-family/laurel/internal/collectors/probe"
+	"github.com/aide-family/laurel/internal/collectors/promtext"
 	"github.com/aide-family/laurel/internal/collectors/system"
+	"github.com/aide-family/laurel/internal/collectors/textfile"
 	"github.com/aide-family/laurel/internal/config"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+var descNamePattern = regexp.MustCompile(`^Desc\{fqName: "([^"]*)"`)
+
 type Exporter struct {
 	registry *prometheus.Registry
 	config   *config.Config
@@ -25,6 +36,40 @@ func NewExporter(registry *prometheus.Registry, config *config.Config) *Exporter
 func (e *Exporter) Start(ctx context.Context) error {
 	systemCollector := system.NewSystemCollector(&e.config.SystemCollectorConfig)
 	e.registry.MustRegister(systemCollector...)
+
+	builtinNames := describedNames(systemCollector...)
+	reserved := func(name string) bool {
+		return builtinNames[name]
+	}
+	// Collectors with fixed metric names go first, so the textfile and exec
+	// collectors can reject output clashing with them.
+	if collector, err := probe.NewProbeCollector(&e.config.ProbeCollectorConfig); e.register(collector, err) {
+		maps.Copy(builtinNames, describedNames(collector))
+	}
+	if collector, err := certificate.NewCertificateCollector(&e.config.CertificateCollectorConfig); e.register(collector, err) {
+		maps.Copy(builtinNames, describedNames(collector))
+	}
+	if collector, err := filewatch.NewFileWatchCollector(&e.config.FileWatchCollectorConfig); e.register(collector, err) {
+		maps.Copy(builtinNames, describedNames(collector))
+	}
+	if collector, err := logtail.NewLogTailCollector(&e.config.LogTailCollectorConfig, reserved); e.register(collector, err) {
+		maps.Copy(builtinNames, describedNames(collector))
+	}
+	// The textfile and exec output is merged into one set of families, as
+	// the registry fails the scrape when both define a family differently
+	var sources []promtext.Source
+	if source, err := textfile.NewTextfileCollector(&e.config.TextfileCollectorConfig, reserved); err != nil {
+		slog.Warn("failed to create collector", "error", err)
+	} else {
+		sources = append(sources, source)
+	}
+	if source, err := exec.NewExecCollector(&e.config.ExecCollectorConfig, reserved); err != nil {
+		slog.Warn("failed to create collector", "error", err)
+	} else {
+		sources = append(sources, source)
+	}
+	e.registry.MustRegister(promtext.NewMergedCollector(sources...))
+
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.HandlerFor(e.registry, promhttp.HandlerOpts{}))
 	e.server = &http.Server{
@@ -39,6 +84,37 @@ func (e *Exporter) Start(ctx context.Context) error {
 	return nil
 }
 
+// register adds a collector to the registry, logging and skipping it when
+// construction failed. It reports whether the collector was registered.
+func (e *Exporter) register(collector prometheus.Collector, err error) bool {
+	if err != nil {
+		slog.Warn("failed to create collector", "error", err)
+		return false
+	}
+	e.registry.MustRegister(collector)
+	return true
+}
+
+// describedNames returns the names of the metrics the collectors describe.
+// prometheus.Desc does not expose its name, so it is taken from the string
+// form of the descriptor.
+func describedNames(collectors ...prometheus.Collector) map[string]bool {
+	descCh := make(chan *prometheus.Desc)
+	go func() {
+		for _, collector := range collectors {
+			collector.Describe(descCh)
+		}
+		close(descCh)
+	}()
+	names := make(map[string]bool)
+	for desc := range descCh {
+		if m := descNamePattern.FindStringSubmatch(desc.String()); m != nil {
+			names[m[1]] = true
+		}
+	}
+	return names
+}
+
 func (e *Exporter) Stop(ctx context.Context) error {
 	if err := e.server.Shutdown(ctx); err != nil {
 		slog.Error("failed to stop server", "error", err)